@@ -0,0 +1,123 @@
+package benchttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// BodyProvider provides the body of a request that is sent multiple times.
+// Open is called for each request and must return a new reader
+// of the body, so that bytes, files and generated payloads can all
+// be replayed safely.
+type BodyProvider struct {
+	// Open returns a new reader of the body.
+	Open func() (io.ReadCloser, error)
+	// Size is the size of the body in bytes, or -1 if unknown,
+	// in which case the body is sent with a chunked encoding.
+	Size int64
+	// Template, if true, renders the body as a template for each
+	// request, like the URL and the header values (see Runner.Seed).
+	// The body is then read in memory for each request. Other bodies
+	// are sent untouched.
+	Template bool
+}
+
+// BytesBody returns a BodyProvider of b.
+func BytesBody(b []byte) BodyProvider {
+	return BodyProvider{
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		},
+		Size: int64(len(b)),
+	}
+}
+
+// StringBody returns a BodyProvider of s.
+func StringBody(s string) BodyProvider {
+	return BodyProvider{
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(s)), nil
+		},
+		Size: int64(len(s)),
+	}
+}
+
+// FileBody returns a BodyProvider of the content of the file at path.
+// The file is opened and read for each request rather than loaded
+// in memory. It returns a non-nil error if the file cannot be accessed.
+func FileBody(path string) (BodyProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return BodyProvider{}, err
+	}
+	if info.IsDir() {
+		return BodyProvider{}, errors.New(path + ": is a directory")
+	}
+	return BodyProvider{
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		Size: info.Size(),
+	}, nil
+}
+
+// FuncBody returns a BodyProvider of the bodies returned by open,
+// e.g. generated payloads. Their size is unknown.
+func FuncBody(open func() (io.ReadCloser, error)) BodyProvider {
+	return BodyProvider{Open: open, Size: -1}
+}
+
+// SetRequestBody sets the body of req to the body provided by p,
+// in a way that allows req to be sent multiple times.
+func SetRequestBody(req *http.Request, p BodyProvider) {
+	req.Body = &lazyBody{open: p.Open, template: p.Template}
+	req.GetBody = p.Open
+	req.ContentLength = p.Size
+}
+
+// lazyBody is a body that is opened on first read, so that setting
+// a body does not open a resource that may never be read, e.g. a file.
+type lazyBody struct {
+	open     func() (io.ReadCloser, error)
+	rc       io.ReadCloser
+	err      error
+	template bool
+}
+
+// IsTemplate implements render.TemplateBody.
+func (b *lazyBody) IsTemplate() bool {
+	return b.template
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	if b.rc == nil && b.err == nil {
+		b.rc, b.err = b.open()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.rc.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	if b.rc == nil {
+		return nil
+	}
+	return b.rc.Close()
+}
+
+// errNotReplayable returns the error of a non-replayable body at path.
+func errNotReplayable(path string) error {
+	return fmt.Errorf("%s: want a replayable body (non-nil GetBody), e.g. set via SetRequestBody", path)
+}
+
+// isReplayable returns true if the body of req can be read again
+// for each request sent: if it has no body or it has a GetBody.
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
@@ -0,0 +1,185 @@
+package benchttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/benchttp/engine/benchttp"
+)
+
+func TestBodyProvider(t *testing.T) {
+	const content = `{"key":"value"}`
+
+	path := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fileBody, err := benchttp.FileBody(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		label   string
+		body    benchttp.BodyProvider
+		expSize int64
+	}{
+		{label: "bytes", body: benchttp.BytesBody([]byte(content)), expSize: 15},
+		{label: "string", body: benchttp.StringBody(content), expSize: 15},
+		{label: "file", body: fileBody, expSize: 15},
+		{
+			label: "func",
+			body: benchttp.FuncBody(func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(content)), nil
+			}),
+			expSize: -1,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "http://a.b", nil)
+			benchttp.SetRequestBody(req, tc.body)
+
+			if req.ContentLength != tc.expSize {
+				t.Errorf("exp ContentLength %d, got %d", tc.expSize, req.ContentLength)
+			}
+
+			// Read the body, then replay it twice.
+			for i, getBody := range []func() (io.ReadCloser, error){
+				func() (io.ReadCloser, error) { return req.Body, nil },
+				req.GetBody,
+				req.GetBody,
+			} {
+				body, err := getBody()
+				if err != nil {
+					t.Fatalf("read %d: unexpected error: %v", i, err)
+				}
+				b, err := io.ReadAll(body)
+				body.Close()
+				if err != nil || string(b) != content {
+					t.Errorf("read %d: exp %q, got %q (%v)", i, content, b, err)
+				}
+			}
+		})
+	}
+
+	t.Run("file body returns an error for a missing file", func(t *testing.T) {
+		_, err := benchttp.FileBody(filepath.Join(t.TempDir(), "missing.json"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("exp os.ErrNotExist, got %v", err)
+		}
+	})
+}
+
+func TestRunner_WithBody(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		gotBodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		gotBodies = append(gotBodies, string(b))
+	}))
+	defer srv.Close()
+
+	runner := benchttp.DefaultRunner().
+		WithNewRequest("POST", srv.URL, nil).
+		WithBody(benchttp.StringBody("hello"))
+	runner.Requests = 3
+	runner.Concurrency = 1
+
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first body is sent by the connection check.
+	exp := "hello hello hello hello"
+	if got := strings.Join(gotBodies, " "); got != exp {
+		t.Errorf("unexpected bodies:\nexp %s\ngot %s", exp, got)
+	}
+}
+
+func TestRunner_WithBody_templates(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		gotBodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		gotBodies = append(gotBodies, string(b))
+	}))
+	defer srv.Close()
+
+	run := func(body benchttp.BodyProvider) (*benchttp.Report, error) {
+		gotBodies = nil
+		runner := benchttp.DefaultRunner().
+			WithNewRequest("POST", srv.URL, nil).
+			WithBody(body)
+		runner.Requests = 2
+		runner.Concurrency = 1
+		return runner.Run(context.Background())
+	}
+
+	t.Run("render template bodies", func(t *testing.T) {
+		body := benchttp.StringBody("item {{.Iteration}}")
+		body.Template = true
+
+		if _, err := run(body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The first body is sent by the connection check.
+		exp := "item 0,item 0,item 1"
+		if got := strings.Join(gotBodies, ","); got != exp {
+			t.Errorf("unexpected bodies:\nexp %s\ngot %s", exp, got)
+		}
+	})
+
+	t.Run("send other bodies untouched", func(t *testing.T) {
+		const content = "\x00{{\x00\xff"
+		path := filepath.Join(t.TempDir(), "payload.bin")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		body, err := benchttp.FileBody(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := run(body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n := report.Metrics.RequestFailureCount(); n != 0 {
+			t.Errorf("exp no failure, got %d: %v", n, report.Metrics.RequestFailures)
+		}
+		for i, got := range gotBodies {
+			if got != content {
+				t.Errorf("bodies[%d]: exp %q, got %q", i, content, got)
+			}
+		}
+	})
+
+	t.Run("return ErrRender for invalid templates", func(t *testing.T) {
+		body := benchttp.StringBody("{{.missing}}")
+		body.Template = true
+
+		if _, err := run(body); !errors.Is(err, benchttp.ErrRender) {
+			t.Errorf("exp ErrRender, got %v", err)
+		}
+	})
+}
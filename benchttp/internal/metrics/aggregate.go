@@ -16,14 +16,48 @@ type Aggregate struct {
 	// slice recorder.Record. It offers statistics about the
 	// recorder.Record.Time of the records.
 	ResponseTimes timestats.TimeStats
+	// NewConnectionsResponseTimes and ReusedConnectionsResponseTimes
+	// are the common statistics of the recorder.Record.Time of the
+	// requests sent on a new connection and on a reused one, to tell
+	// the latency caused by connection churn from the server's.
+	// Failed requests are excluded.
+	NewConnectionsResponseTimes    timestats.TimeStats
+	ReusedConnectionsResponseTimes timestats.TimeStats
+	// NewConnectionCount and ReusedConnectionCount are the counts
+	// of requests sent on a new connection and on a reused one.
+	// Failed requests are excluded.
+	NewConnectionCount    int
+	ReusedConnectionCount int
+	// SigningTimes is the common statistics computed from the
+	// recorder.Record.SignTime of the signed records. It is the zero
+	// value if the requests are not signed.
+	SigningTimes timestats.TimeStats
 	// StatusCodesDistribution maps each status code received to
 	// its number of occurrence.
 	StatusCodesDistribution map[int]int
+	// ProtocolsDistribution maps each protocol of the responses
+	// received, e.g. "HTTP/2.0", to its number of occurrence.
+	ProtocolsDistribution map[string]int
+	// TLSVersionsDistribution maps each TLS version negotiated, e.g.
+	// "TLS 1.3", to its number of occurrence. Only the requests that
+	// opened a new TLS connection are counted, as for the other
+	// TLS distributions.
+	TLSVersionsDistribution map[string]int
+	// TLSCipherSuitesDistribution maps each TLS cipher suite negotiated
+	// to its number of occurrence.
+	TLSCipherSuitesDistribution map[string]int
+	// TLSSessionsDistribution maps the kinds of TLS handshakes, "full"
+	// or "resumed" for resumed sessions, to their number of occurrence.
+	TLSSessionsDistribution map[string]int
 	// RequestEventTimes is the common statistics computed from
 	// the combination of all recorder.Record.Events of a slice
 	// of recorder.Record. It offers statistics about the
 	// recorder.Events.Time of the records.
 	RequestEventTimes map[string]timestats.TimeStats
+	// Phases is the common statistics computed from the durations
+	// of each phase of the requests: DNS, Connect, TLS, Write,
+	// Wait (time to first byte) and Transfer.
+	Phases PhasesAggregate
 	// Records lists each response time received during the run.
 	// It offers raw informarion.
 	Records []struct {
@@ -34,33 +68,113 @@ type Aggregate struct {
 	RequestFailures []struct {
 		Reason string
 	}
+	// InvalidResponseCount is the count of responses that failed
+	// one of the checks of the run. Their requests are not counted
+	// as failures but are not successful either.
+	InvalidResponseCount int
+	// InvalidResponsesDistribution maps the reason of the invalid
+	// responses, i.e. the name of the check they failed, to its
+	// number of occurrence.
+	InvalidResponsesDistribution map[string]int
+	// RecordingWindow is the time span of the records, from the start
+	// of the earliest request to the end of the latest one to end.
+	RecordingWindow time.Duration
+	// RequestsPerSecond and SuccessfulRequestsPerSecond are the counts
+	// of requests and of successful requests per second over
+	// the RecordingWindow.
+	RequestsPerSecond           float64
+	SuccessfulRequestsPerSecond float64
+	// ResponseSizes is the common statistics of the recorder.Record.Bytes
+	// of the requests that did not fail.
+	ResponseSizes SizeStats
+	// BytesReceived and BytesSent are the total sizes in bytes of the
+	// bodies of the responses received and of the requests sent.
+	BytesReceived int
+	BytesSent     int
+	// BytesReceivedPerSecond and BytesSentPerSecond are the bytes
+	// received and sent per second over the RecordingWindow.
+	BytesReceivedPerSecond float64
+	BytesSentPerSecond     float64
+	// RequestCanceledCount is the count of requests interrupted because
+	// the run was canceled or timed out. They are neither counted
+	// as failures nor included in the other metrics.
+	RequestCanceledCount int
+	// Requests maps each request name of a mix to the Aggregate
+	// computed from its records only. It is nil if the records
+	// are not named.
+	Requests map[string]Aggregate
+	// TimeSeries is the sequence of the aggregates of the consecutive
+	// time windows of the run. It is computed from all the records
+	// only, and is nil for the aggregates of Requests.
+	TimeSeries TimeSeries
+	// Iterations is the aggregate of the full iterations of a scenario,
+	// the metrics of each step being available in Requests by step name.
+	// It is the zero value if no scenario was run.
+	Iterations IterationAggregate
 }
 
 // NewAggregate computes and aggregates metrics from the given records.
-func NewAggregate(records []recorder.Record) (agg Aggregate) {
+// Its statistics include the given percentiles, or
+// timestats.DefaultPercentiles if none are given.
+func NewAggregate(records []recorder.Record, percentiles ...float64) Aggregate {
+	agg := newAggregate(records, percentiles)
+	agg.Requests = computeRequestsAggregates(records, percentiles)
+	return agg
+}
+
+// newAggregate computes the metrics of the records as a whole.
+func newAggregate(records []recorder.Record, percentiles []float64) (agg Aggregate) {
+	records, agg.RequestCanceledCount = excludeCanceled(records)
 	if len(records) == 0 {
 		return
 	}
 
 	times := make([]time.Duration, len(records))
+	signTimes := []time.Duration{}
 	for i, rec := range records {
 		agg.Records = append(agg.Records, struct{ ResponseTime time.Duration }{rec.Time})
 		times[i] = rec.Time
+		if rec.SignTime > 0 {
+			signTimes = append(signTimes, rec.SignTime)
+		}
 		if rec.Error != "" {
 			agg.RequestFailures = append(agg.RequestFailures, struct{ Reason string }{rec.Error})
 		}
 	}
 
-	agg.ResponseTimes = timestats.New(times)
+	agg.ResponseTimes = timestats.New(times, percentiles...)
+
+	newConnTimes, reusedConnTimes := splitTimesByConnection(records)
+	agg.NewConnectionCount = len(newConnTimes)
+	agg.ReusedConnectionCount = len(reusedConnTimes)
+	agg.NewConnectionsResponseTimes = timestats.New(newConnTimes, percentiles...)
+	agg.ReusedConnectionsResponseTimes = timestats.New(reusedConnTimes, percentiles...)
+
+	if len(signTimes) > 0 {
+		agg.SigningTimes = timestats.New(signTimes, percentiles...)
+	}
 
 	agg.StatusCodesDistribution = computeStatusCodesDistribution(records)
 
-	agg.RequestEventTimes = computeRequestEventTimes(records)
+	agg.ProtocolsDistribution = computeProtocolsDistribution(records)
+
+	agg.InvalidResponsesDistribution, agg.InvalidResponseCount =
+		computeInvalidResponsesDistribution(records)
+
+	computeThroughput(&agg, records, percentiles)
+
+	agg.TLSVersionsDistribution, agg.TLSCipherSuitesDistribution, agg.TLSSessionsDistribution =
+		computeTLSDistributions(records)
+
+	agg.RequestEventTimes = computeRequestEventTimes(records, percentiles)
+
+	agg.Phases = newPhasesAggregate(records, percentiles)
 
 	return agg
 }
 
-// RequestCount returns the total count of requests done.
+// RequestCount returns the total count of requests done,
+// excluding canceled ones.
 func (agg Aggregate) RequestCount() int {
 	return len(agg.Records)
 }
@@ -70,14 +184,48 @@ func (agg Aggregate) RequestFailureCount() int {
 	return len(agg.RequestFailures)
 }
 
-// RequestSuccessCount returns the count of successful requests.
+// RequestSuccessCount returns the count of successful requests,
+// i.e. neither failed nor with an invalid response.
 func (agg Aggregate) RequestSuccessCount() int {
-	return agg.RequestCount() - agg.RequestFailureCount()
+	return agg.RequestCount() - agg.RequestFailureCount() - agg.InvalidResponseCount
 }
 
 // Special compute helpers.
 
-func computeRequestEventTimes(records []recorder.Record) map[string]timestats.TimeStats {
+// excludeCanceled returns the records that are not canceled
+// and the count of canceled ones.
+func excludeCanceled(records []recorder.Record) ([]recorder.Record, int) {
+	completed := make([]recorder.Record, 0, len(records))
+	for _, rec := range records {
+		if !rec.Canceled {
+			completed = append(completed, rec)
+		}
+	}
+	return completed, len(records) - len(completed)
+}
+
+// computeRequestsAggregates returns the Aggregate of each group
+// of records of a same name, or nil if the records are not named.
+func computeRequestsAggregates(records []recorder.Record, percentiles []float64) map[string]Aggregate {
+	recordsByName := map[string][]recorder.Record{}
+	for _, rec := range records {
+		if rec.Name != "" {
+			recordsByName[rec.Name] = append(recordsByName[rec.Name], rec)
+		}
+	}
+
+	if len(recordsByName) == 0 {
+		return nil
+	}
+
+	aggByName := make(map[string]Aggregate, len(recordsByName))
+	for name, group := range recordsByName {
+		aggByName[name] = newAggregate(group, percentiles)
+	}
+	return aggByName
+}
+
+func computeRequestEventTimes(records []recorder.Record, percentiles []float64) map[string]timestats.TimeStats {
 	events := flattenRelativeTimeEvents(records)
 
 	timesByEvent := map[string][]time.Duration{}
@@ -89,7 +237,7 @@ func computeRequestEventTimes(records []recorder.Record) map[string]timestats.Ti
 	statsByEvent := map[string]timestats.TimeStats{}
 
 	for e, times := range timesByEvent {
-		statsByEvent[e] = timestats.New(times)
+		statsByEvent[e] = timestats.New(times, percentiles...)
 	}
 
 	return statsByEvent
@@ -110,3 +258,61 @@ func computeStatusCodesDistribution(records []recorder.Record) map[int]int {
 	}
 	return statuses
 }
+
+func computeProtocolsDistribution(records []recorder.Record) map[string]int {
+	protocols := map[string]int{}
+	for _, rec := range records {
+		if rec.Proto != "" {
+			protocols[rec.Proto]++
+		}
+	}
+	return protocols
+}
+
+func computeTLSDistributions(records []recorder.Record) (versions, cipherSuites, sessions map[string]int) {
+	versions, cipherSuites, sessions = map[string]int{}, map[string]int{}, map[string]int{}
+	for _, rec := range records {
+		if rec.TLSVersion == "" {
+			continue
+		}
+		versions[rec.TLSVersion]++
+		cipherSuites[rec.TLSCipherSuite]++
+		if rec.TLSResumed {
+			sessions["resumed"]++
+		} else {
+			sessions["full"]++
+		}
+	}
+	return versions, cipherSuites, sessions
+}
+
+// computeInvalidResponsesDistribution returns the count of invalid
+// responses by reason and their total count.
+func computeInvalidResponsesDistribution(records []recorder.Record) (map[string]int, int) {
+	reasons := map[string]int{}
+	count := 0
+	for _, rec := range records {
+		if rec.InvalidReason != "" {
+			reasons[rec.InvalidReason]++
+			count++
+		}
+	}
+	return reasons, count
+}
+
+// splitTimesByConnection returns the times of the records that did not
+// fail, split by whether their request was sent on a new connection
+// or on a reused one.
+func splitTimesByConnection(records []recorder.Record) (newConn, reusedConn []time.Duration) {
+	for _, rec := range records {
+		switch {
+		case rec.Error != "":
+			continue
+		case rec.ConnReused:
+			reusedConn = append(reusedConn, rec.Time)
+		default:
+			newConn = append(newConn, rec.Time)
+		}
+	}
+	return newConn, reusedConn
+}
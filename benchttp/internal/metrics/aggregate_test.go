@@ -10,6 +10,70 @@ import (
 	"github.com/benchttp/engine/benchttp/internal/recorder"
 )
 
+func TestNewTimeSeries(t *testing.T) {
+	start := time.Now()
+	at := func(offset time.Duration) time.Time { return start.Add(offset) }
+
+	input := []recorder.Record{
+		{Start: at(0), Time: 100},
+		{Start: at(400 * time.Millisecond), Time: 300, Error: "wrong"},
+		{Start: at(900 * time.Millisecond), Time: 200},
+		{Start: at(1200 * time.Millisecond), Canceled: true},
+		{Start: at(2100 * time.Millisecond), Time: 500},
+	}
+
+	want := metrics.TimeSeries{
+		{Start: 0, RequestCount: 2, Throughput: 4, ErrorCount: 1},
+		{Start: 500 * time.Millisecond, RequestCount: 1, Throughput: 2},
+		{Start: time.Second},
+		{Start: 1500 * time.Millisecond},
+		{Start: 2 * time.Second, RequestCount: 1, Throughput: 2},
+	}
+	wantMax := []time.Duration{300, 200, 0, 0, 500}
+
+	got := metrics.NewTimeSeries(input, 500*time.Millisecond)
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d windows, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].ResponseTimes.Max != wantMax[i] {
+			t.Errorf("windows[%d].ResponseTimes.Max: want %v, got %v", i, wantMax[i], got[i].ResponseTimes.Max)
+		}
+		got[i].ResponseTimes = metrics.TimeStats{}
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("windows[%d]: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	t.Run("use default window", func(t *testing.T) {
+		if got := metrics.NewTimeSeries(input, 0); len(got) != 3 {
+			t.Errorf("want 3 windows of 1s, got %d", len(got))
+		}
+	})
+
+	t.Run("use minimum window", func(t *testing.T) {
+		got := metrics.NewTimeSeries(input, time.Nanosecond)
+		if len(got) != 211 {
+			t.Errorf("want 211 windows of 10ms, got %d", len(got))
+		}
+	})
+
+	t.Run("limit the number of windows", func(t *testing.T) {
+		long := []recorder.Record{{Start: at(0)}, {Start: at(24 * time.Hour)}}
+		got := metrics.NewTimeSeries(long, metrics.MinTimeSeriesWindow)
+		if len(got) > metrics.MaxTimeSeriesWindows {
+			t.Fatalf("want at most %d windows, got %d", metrics.MaxTimeSeriesWindows, len(got))
+		}
+		if got[1].Start%time.Millisecond != 0 {
+			t.Errorf("want a window of whole milliseconds, got %v", got[1].Start)
+		}
+		if last := got[len(got)-1]; last.RequestCount != 1 {
+			t.Errorf("want last record in last window, got %+v", last)
+		}
+	})
+}
+
 func TestNewAggregate(t *testing.T) {
 	// Test for "response times stats" is delegated to timestats.New because
 	// metrics.NewAggregate does not have any specific behavior aound it.
@@ -70,6 +134,167 @@ func TestNewAggregate(t *testing.T) {
 		}
 	})
 
+	t.Run("protocols stats", func(t *testing.T) {
+		input := []recorder.Record{
+			{Proto: "HTTP/2.0"}, {Proto: "HTTP/2.0"}, {Proto: "HTTP/1.1"}, {Error: "wrong"},
+		}
+
+		want := map[string]int{"HTTP/2.0": 2, "HTTP/1.1": 1}
+
+		got := metrics.NewAggregate(input).ProtocolsDistribution
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ProtocolsDistribution: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("tls stats", func(t *testing.T) {
+		input := []recorder.Record{
+			{TLSVersion: "TLS 1.3", TLSCipherSuite: "TLS_AES_128_GCM_SHA256"},
+			{TLSVersion: "TLS 1.3", TLSCipherSuite: "TLS_AES_128_GCM_SHA256", TLSResumed: true},
+			{TLSVersion: "TLS 1.2", TLSCipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			{Code: 200}, // reused connection
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		for _, tc := range []struct {
+			name      string
+			got, want map[string]int
+		}{
+			{
+				name: "TLSVersionsDistribution",
+				got:  agg.TLSVersionsDistribution,
+				want: map[string]int{"TLS 1.3": 2, "TLS 1.2": 1},
+			},
+			{
+				name: "TLSCipherSuitesDistribution",
+				got:  agg.TLSCipherSuitesDistribution,
+				want: map[string]int{"TLS_AES_128_GCM_SHA256": 2, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256": 1},
+			},
+			{
+				name: "TLSSessionsDistribution",
+				got:  agg.TLSSessionsDistribution,
+				want: map[string]int{"full": 2, "resumed": 1},
+			},
+		} {
+			if !reflect.DeepEqual(tc.got, tc.want) {
+				t.Errorf("%s: want %v, got %v", tc.name, tc.want, tc.got)
+			}
+		}
+	})
+
+	t.Run("connection reuse stats", func(t *testing.T) {
+		input := []recorder.Record{
+			{Time: 300},
+			{Time: 100, ConnReused: true},
+			{Time: 500},
+			{Time: 200, ConnReused: true, ConnWasIdle: true},
+			{Time: 400, ConnReused: true},
+			{Error: "wrong"},
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		if agg.NewConnectionCount != 2 || agg.ReusedConnectionCount != 3 {
+			t.Errorf("want 2 new and 3 reused connections, got %d and %d",
+				agg.NewConnectionCount, agg.ReusedConnectionCount)
+		}
+		if got := agg.NewConnectionsResponseTimes.Mean; got != 400 {
+			t.Errorf("NewConnectionsResponseTimes.Mean: want 400, got %v", got)
+		}
+		if got := agg.ReusedConnectionsResponseTimes.Mean; got != 233 {
+			t.Errorf("ReusedConnectionsResponseTimes.Mean: want 233, got %v", got)
+		}
+	})
+
+	t.Run("throughput stats", func(t *testing.T) {
+		start := time.Now()
+		input := []recorder.Record{
+			{Start: start, Time: 500 * time.Millisecond, Bytes: 100, BytesSent: 10},
+			{Start: start.Add(time.Second), Time: time.Second, Bytes: 300, BytesSent: 10},
+			{Start: start.Add(3 * time.Second), Time: time.Second, Error: "wrong", BytesSent: 10},
+			{Start: start.Add(3 * time.Second), Canceled: true},
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		if agg.RecordingWindow != 4*time.Second {
+			t.Errorf("RecordingWindow: want 4s, got %v", agg.RecordingWindow)
+		}
+		for _, tc := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"RequestsPerSecond", agg.RequestsPerSecond, 0.75},
+			{"SuccessfulRequestsPerSecond", agg.SuccessfulRequestsPerSecond, 0.5},
+			{"BytesReceivedPerSecond", agg.BytesReceivedPerSecond, 100},
+			{"BytesSentPerSecond", agg.BytesSentPerSecond, 7.5},
+		} {
+			if tc.got != tc.want {
+				t.Errorf("%s: want %v, got %v", tc.name, tc.want, tc.got)
+			}
+		}
+		if agg.BytesReceived != 400 || agg.BytesSent != 30 {
+			t.Errorf("want 400 bytes received and 30 sent, got %d and %d",
+				agg.BytesReceived, agg.BytesSent)
+		}
+		sizes := agg.ResponseSizes
+		if sizes.Min != 100 || sizes.Max != 300 || sizes.Mean != 200 || sizes.Percentiles["P90"] != 280 {
+			t.Errorf("ResponseSizes: want min 100, max 300, mean 200 and p90 280, got %+v", sizes)
+		}
+	})
+
+	t.Run("phases stats", func(t *testing.T) {
+		input := []recorder.Record{
+			{Phases: recorder.Phases{DNS: 10, Connect: 20, TLS: 40, Write: 1, Wait: 100, Transfer: 5}},
+			{Phases: recorder.Phases{DNS: 30, Connect: 40, TLS: 60, Write: 3, Wait: 300, Transfer: 15}},
+			{Phases: recorder.Phases{Write: 2, Wait: 200, Transfer: 10}}, // reused connection
+		}
+
+		got := metrics.NewAggregate(input).Phases
+
+		for _, tc := range []struct {
+			name     string
+			got      metrics.TimeStats
+			wantMean time.Duration
+		}{
+			{name: "DNS", got: got.DNS, wantMean: 20},
+			{name: "Connect", got: got.Connect, wantMean: 30},
+			{name: "TLS", got: got.TLS, wantMean: 50},
+			{name: "Write", got: got.Write, wantMean: 2},
+			{name: "Wait", got: got.Wait, wantMean: 200},
+			{name: "Transfer", got: got.Transfer, wantMean: 10},
+		} {
+			if tc.got.Mean != tc.wantMean {
+				t.Errorf("Phases.%s.Mean: want %v, got %v", tc.name, tc.wantMean, tc.got.Mean)
+			}
+		}
+	})
+
+	t.Run("invalid responses", func(t *testing.T) {
+		input := []recorder.Record{
+			{Code: 500, InvalidReason: "status"},
+			{Code: 200, InvalidReason: "status ok"},
+			{Code: 503, InvalidReason: "status"},
+			{Code: 200},
+			{Error: "wrong"},
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		want := map[string]int{"status": 2, "status ok": 1}
+		if !reflect.DeepEqual(agg.InvalidResponsesDistribution, want) {
+			t.Errorf("InvalidResponsesDistribution: want %v, got %v", want, agg.InvalidResponsesDistribution)
+		}
+		if agg.InvalidResponseCount != 3 {
+			t.Errorf("InvalidResponseCount: want 3, got %d", agg.InvalidResponseCount)
+		}
+		if got := agg.RequestSuccessCount(); got != 1 {
+			t.Errorf("RequestSuccessCount: want 1, got %d", got)
+		}
+	})
+
 	t.Run("records", func(t *testing.T) {
 		input := []recorder.Record{
 			{Time: 100}, {Time: 50}, {Time: 100}, {Time: 200}, {Time: 150},
@@ -97,6 +322,82 @@ func TestNewAggregate(t *testing.T) {
 			t.Errorf("RequestFailures: want %v, got %v", want, got)
 		}
 	})
+
+	t.Run("signing times", func(t *testing.T) {
+		input := []recorder.Record{
+			{Time: 100, SignTime: 10}, {Time: 100, SignTime: 30}, {Time: 100},
+		}
+
+		got := metrics.NewAggregate(input).SigningTimes
+
+		if got.Min != 10 || got.Max != 30 || got.Mean != 20 {
+			t.Errorf("SigningTimes: want min 10, max 30, mean 20, got %+v", got)
+		}
+
+		if unsigned := metrics.NewAggregate(input[2:]).SigningTimes; !reflect.ValueOf(unsigned).IsZero() {
+			t.Errorf("SigningTimes: want zero value for unsigned records, got %+v", unsigned)
+		}
+	})
+
+	t.Run("canceled requests", func(t *testing.T) {
+		input := []recorder.Record{
+			{Code: 200, Time: 100}, {Error: "wrong"}, {Canceled: true}, {Canceled: true},
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		if got := agg.RequestCanceledCount; got != 2 {
+			t.Errorf("RequestCanceledCount: want 2, got %d", got)
+		}
+
+		if got := agg.RequestCount(); got != 2 {
+			t.Errorf("RequestCount: want 2, got %d", got)
+		}
+
+		if got := agg.RequestFailureCount(); got != 1 {
+			t.Errorf("RequestFailureCount: want 1, got %d", got)
+		}
+
+		want := map[int]int{200: 1, 0: 1}
+		if got := agg.StatusCodesDistribution; !reflect.DeepEqual(got, want) {
+			t.Errorf("StatusCodesDistribution: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("requests by name", func(t *testing.T) {
+		input := []recorder.Record{
+			{Name: "login", Time: 100}, {Name: "browse", Time: 10},
+			{Name: "browse", Time: 20}, {Name: "browse", Error: "wrong"},
+		}
+
+		agg := metrics.NewAggregate(input)
+
+		if got := agg.RequestCount(); got != 4 {
+			t.Errorf("RequestCount: want 4, got %d", got)
+		}
+
+		login, browse := agg.Requests["login"], agg.Requests["browse"]
+
+		if got := login.RequestCount(); got != 1 {
+			t.Errorf("Requests.login.RequestCount: want 1, got %d", got)
+		}
+		if got := login.ResponseTimes.Max; got != 100 {
+			t.Errorf("Requests.login.ResponseTimes.Max: want 100, got %d", got)
+		}
+
+		if got := browse.RequestCount(); got != 3 {
+			t.Errorf("Requests.browse.RequestCount: want 3, got %d", got)
+		}
+		if got := browse.RequestFailureCount(); got != 1 {
+			t.Errorf("Requests.browse.RequestFailureCount: want 1, got %d", got)
+		}
+	})
+
+	t.Run("no requests by name for unnamed records", func(t *testing.T) {
+		if got := metrics.NewAggregate([]recorder.Record{{}}).Requests; got != nil {
+			t.Errorf("Requests: want nil, got %v", got)
+		}
+	})
 }
 
 // approxEqual returns true if val is equal to target with a margin of error.
@@ -30,6 +30,9 @@ func compareMetrics(m, n Metric) ComparisonResult {
 	if a, b, isInt := assertInts(a, b); isInt {
 		return compareInts(a, b)
 	}
+	if a, b, isFloat := assertFloats(a, b); isFloat {
+		return compareFloats(a, b)
+	}
 	panic(fmt.Sprintf(
 		"metrics: unhandled comparison: %v (%T) and %v (%T)",
 		a, a, b, b,
@@ -48,6 +51,18 @@ func compareInts(a, b int) ComparisonResult {
 	return EQ
 }
 
+// compareFloats compares a and b and returns a ComparisonResult
+// from the point of view of b.
+func compareFloats(a, b float64) ComparisonResult {
+	if b < a {
+		return INF
+	}
+	if b > a {
+		return SUP
+	}
+	return EQ
+}
+
 // compareInts compares a and b and returns a ComparisonResult
 // from the point of view of b.
 func compareDurations(a, b time.Duration) ComparisonResult {
@@ -75,3 +90,14 @@ func assertDurations(a, b Value) (x, y time.Duration, ok bool) {
 	y, ok = b.(time.Duration)
 	return
 }
+
+// assertFloats returns a, b as float64s and true if a and b
+// are both float64s, else it returns 0, 0, false.
+func assertFloats(a, b Value) (x, y float64, ok bool) {
+	x, ok = a.(float64)
+	if !ok {
+		return
+	}
+	y, ok = b.(float64)
+	return
+}
@@ -2,6 +2,13 @@ package metrics
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics/timestats"
+	"github.com/benchttp/engine/benchttp/internal/reflectpath"
 
 	"github.com/benchttp/engine/internal/errorutil"
 )
@@ -22,11 +29,66 @@ func (f Field) Type() string {
 	return Aggregate{}.typeOf(f)
 }
 
+// hasWildcard returns true if f contains a wildcard segment.
+func (f Field) hasWildcard() bool {
+	for _, segment := range strings.Split(string(f), ".") {
+		if segment == reflectpath.Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
 // Validate returns an ErrUnknownField if it does not correspond
-// to a valid path from an Aggregate.
-func (f Field) Validate() error {
+// to a valid path from an Aggregate whose statistics include the given
+// percentiles, or timestats.DefaultPercentiles if none are given.
+func (f Field) Validate(percentiles ...float64) error {
 	if f.Type() == "" {
 		return errorutil.WithDetails(ErrUnknownField, f)
 	}
+	if len(percentiles) == 0 {
+		percentiles = timestats.DefaultPercentiles
+	}
+	for _, key := range f.percentileKeys() {
+		if !hasPercentileKey(percentiles, key) {
+			return errorutil.WithDetails(
+				ErrUnknownField, f, fmt.Sprintf("percentile %s is not computed", key),
+			)
+		}
+	}
 	return nil
 }
+
+var (
+	timeStatsType = reflect.TypeOf(timestats.TimeStats{}).String()
+	sizeStatsType = reflect.TypeOf(SizeStats{}).String()
+
+	percentileKeyRegexp = regexp.MustCompile(percentileKeyPattern)
+)
+
+// percentileKeys returns the segments of f resolved as the key
+// of a percentile of a TimeStats or a SizeStats, e.g. "P99" for
+// "ResponseTimes.P99".
+func (f Field) percentileKeys() []string {
+	segments := strings.Split(string(f), ".")
+	keys := []string{}
+	for i := 1; i < len(segments); i++ {
+		switch Field(strings.Join(segments[:i], ".")).Type() {
+		case timeStatsType, sizeStatsType:
+			if percentileKeyRegexp.MatchString(segments[i]) {
+				keys = append(keys, segments[i])
+			}
+		}
+	}
+	return keys
+}
+
+// hasPercentileKey returns true if key is the key of one of percentiles.
+func hasPercentileKey(percentiles []float64, key string) bool {
+	for _, p := range percentiles {
+		if strings.EqualFold(timestats.PercentileKey(p), key) {
+			return true
+		}
+	}
+	return false
+}
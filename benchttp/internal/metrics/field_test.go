@@ -32,6 +32,26 @@ func TestField_Type(t *testing.T) {
 			fieldID: "Records.0.ResponseTime",
 			exp:     "time.Duration",
 		},
+		{
+			name:    "percentile",
+			fieldID: "ResponseTimes.P99",
+			exp:     "time.Duration",
+		},
+		{
+			name:    "throughput",
+			fieldID: "RequestsPerSecond",
+			exp:     "float64",
+		},
+		{
+			name:    "size stats",
+			fieldID: "ResponseSizes.Max",
+			exp:     "int",
+		},
+		{
+			name:    "wildcard",
+			fieldID: "TimeSeries.*.Throughput",
+			exp:     "float64",
+		},
 	}
 
 	for _, c := range cases {
@@ -59,6 +79,36 @@ func TestField_Validate(t *testing.T) {
 			fieldID:  "Marcel.Patulacci",
 			expError: "metrics: unknown field: Marcel.Patulacci",
 		},
+		{
+			name:     "default percentile",
+			fieldID:  "ResponseTimes.P99_9",
+			expError: "",
+		},
+		{
+			name:     "unknown stats field",
+			fieldID:  "ResponseTimes.Typo",
+			expError: "metrics: unknown field: ResponseTimes.Typo",
+		},
+		{
+			name:     "percentile not computed",
+			fieldID:  "ResponseTimes.P97",
+			expError: "metrics: unknown field: ResponseTimes.P97: percentile P97 is not computed",
+		},
+		{
+			name:     "size percentile not computed",
+			fieldID:  "ResponseSizes.P75",
+			expError: "metrics: unknown field: ResponseSizes.P75: percentile P75 is not computed",
+		},
+		{
+			name:     "wildcard on struct",
+			fieldID:  "ResponseTimes.*",
+			expError: "metrics: unknown field: ResponseTimes.*",
+		},
+		{
+			name:     "wildcard on nested struct",
+			fieldID:  "Iterations.*",
+			expError: "metrics: unknown field: Iterations.*",
+		},
 	}
 
 	for _, c := range cases {
@@ -78,3 +128,19 @@ func TestField_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestField_Validate_percentiles(t *testing.T) {
+	percentiles := []float64{75, 99.99}
+
+	for _, fieldID := range []string{"ResponseTimes.P75", "Phases.Wait.p99_99", "TimeSeries.*.ResponseTimes.P75"} {
+		if err := metrics.Field(fieldID).Validate(percentiles...); err != nil {
+			t.Errorf("%s: unexpected error: %v", fieldID, err)
+		}
+	}
+
+	for _, fieldID := range []string{"ResponseTimes.P99", "Requests.P75.ResponseTimes.P95"} {
+		if err := metrics.Field(fieldID).Validate(percentiles...); err == nil {
+			t.Errorf("%s: exp error, got nil", fieldID)
+		}
+	}
+}
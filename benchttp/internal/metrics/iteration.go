@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics/timestats"
+	"github.com/benchttp/engine/benchttp/internal/recorder"
+)
+
+// IterationAggregate is an aggregate of metrics computed from
+// a slice of recorder.IterationRecord, i.e. the full iterations
+// of a scenario.
+type IterationAggregate struct {
+	// Times is the common statistics of the durations
+	// of the iterations that were not canceled.
+	Times timestats.TimeStats
+	// Count is the count of iterations that were not canceled.
+	Count int
+	// FailureCount is the count of iterations that stopped
+	// because one of their steps failed.
+	FailureCount int
+	// CanceledCount is the count of iterations interrupted because
+	// the run was canceled or timed out.
+	CanceledCount int
+}
+
+// NewIterationAggregate computes and aggregates metrics
+// from the given iteration records. Its statistics include the given
+// percentiles, or timestats.DefaultPercentiles if none are given.
+func NewIterationAggregate(iterations []recorder.IterationRecord, percentiles ...float64) (agg IterationAggregate) {
+	times := make([]time.Duration, 0, len(iterations))
+	for _, iter := range iterations {
+		switch {
+		case iter.Canceled:
+			agg.CanceledCount++
+			continue
+		case iter.Error != "":
+			agg.FailureCount++
+		}
+		times = append(times, iter.Time)
+	}
+
+	agg.Count = len(times)
+	agg.Times = timestats.New(times, percentiles...)
+	return agg
+}
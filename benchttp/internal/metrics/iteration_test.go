@@ -0,0 +1,29 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics"
+	"github.com/benchttp/engine/benchttp/internal/recorder"
+)
+
+func TestNewIterationAggregate(t *testing.T) {
+	input := []recorder.IterationRecord{
+		{Time: 100}, {Time: 300}, {Time: 200, Error: "step login: wrong"}, {Canceled: true},
+	}
+
+	agg := metrics.NewIterationAggregate(input)
+
+	if agg.Count != 3 {
+		t.Errorf("Count: want 3, got %d", agg.Count)
+	}
+	if agg.FailureCount != 1 {
+		t.Errorf("FailureCount: want 1, got %d", agg.FailureCount)
+	}
+	if agg.CanceledCount != 1 {
+		t.Errorf("CanceledCount: want 1, got %d", agg.CanceledCount)
+	}
+	if agg.Times.Max != 300 || agg.Times.Mean != 200 {
+		t.Errorf("Times: want max 300 and mean 200, got %+v", agg.Times)
+	}
+}
@@ -53,6 +53,23 @@ func (agg Aggregate) MetricOf(field Field) Metric {
 	}
 }
 
+// MetricsOf returns the Metrics for the given field id in Aggregate.
+// The field may contain wildcard segments "*" matching every element
+// of a slice or map, e.g. "TimeSeries.*.ResponseTimes.Max", in which
+// case a Metric is returned for each element, with its concrete field.
+// A field without wildcard results in a single Metric.
+func (agg Aggregate) MetricsOf(field Field) []Metric {
+	if !field.hasWildcard() {
+		return []Metric{agg.MetricOf(field)}
+	}
+	paths, values := pathResolver().ResolveValues(agg, string(field))
+	metrics := make([]Metric, len(values))
+	for i, v := range values {
+		metrics[i] = Metric{Field: Field(paths[i]), Value: v.Interface()}
+	}
+	return metrics
+}
+
 // typeOf returns a string representation of the metric's type
 // represented by a field path.
 func (agg Aggregate) typeOf(field Field) string {
@@ -64,16 +81,34 @@ func (agg Aggregate) typeOf(field Field) string {
 
 var exposedPathPatterns = []string{
 	"(?i)ResponseTimes.*",
+	"(?i)SigningTimes.*",
+	"(?i)(New|Reused)Connection(sResponseTimes.*|Count)",
 	"(?i)StatusCodesDistribution.*",
+	"(?i)ProtocolsDistribution.*",
+	"(?i)TLS(Versions|CipherSuites|Sessions)Distribution.*",
 	"(?i)RequestEventTimes.*",
+	"(?i)Phases\\..+",
 	"(?i)Records.*",
 	"(?i)RequestFailures.*",
-	"(?i)Request(Failure|Success)?Count",
+	"(?i)Request(Failure|Success|Canceled)?Count",
+	"(?i)InvalidResponse(Count|sDistribution.*)",
+	"(?i)RecordingWindow",
+	"(?i)(Successful)?RequestsPerSecond",
+	"(?i)ResponseSizes.*",
+	"(?i)Bytes(Received|Sent)(PerSecond)?",
+	"(?i)Requests\\..+",
+	"(?i)Iterations\\..+",
+	"(?i)TimeSeries\\..+",
 }
 
+// percentileKeyPattern matches the keys of the percentiles of
+// a TimeStats or SizeStats, as returned by timestats.PercentileKey.
+const percentileKeyPattern = `(?i)^P\d+(_\d+)?$`
+
 func pathResolver() reflectpath.Resolver {
 	return reflectpath.Resolver{
-		KeyMatcher:      strings.EqualFold,
-		AllowedPatterns: exposedPathPatterns,
+		KeyMatcher:       strings.EqualFold,
+		AllowedPatterns:  exposedPathPatterns,
+		InlineKeyPattern: percentileKeyPattern,
 	}
 }
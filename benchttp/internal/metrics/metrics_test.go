@@ -1,6 +1,7 @@
 package metrics_test
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -51,9 +52,16 @@ func TestMetric_Compare(t *testing.T) {
 			expPanic:     true,
 		},
 		{
-			label:        "panics with different type",
+			label:        "compare floats",
 			baseMetric:   metricWithValue(1.23),
-			targetMetric: metricWithValue(1.23),
+			targetMetric: metricWithValue(1.24),
+			expResult:    metrics.INF,
+			expPanic:     false,
+		},
+		{
+			label:        "panics with unhandled type",
+			baseMetric:   metricWithValue("1.23"),
+			targetMetric: metricWithValue("1.23"),
 			expResult:    0, // irrelevant, should panic
 			expPanic:     true,
 		},
@@ -76,6 +84,54 @@ func TestMetric_Compare(t *testing.T) {
 	}
 }
 
+func TestAggregate_MetricsOf(t *testing.T) {
+	agg := metrics.Aggregate{
+		ResponseTimes: timestats.TimeStats{Max: time.Second},
+		TimeSeries: metrics.TimeSeries{
+			{RequestCount: 10},
+			{RequestCount: 20},
+		},
+		StatusCodesDistribution: map[int]int{404: 2, 200: 5},
+	}
+
+	testcases := []struct {
+		fieldID string
+		exp     []metrics.Metric
+	}{
+		{
+			fieldID: "ResponseTimes.Max",
+			exp:     []metrics.Metric{{Field: "ResponseTimes.Max", Value: time.Second}},
+		},
+		{
+			fieldID: "TimeSeries.*.RequestCount",
+			exp: []metrics.Metric{
+				{Field: "TimeSeries.0.RequestCount", Value: 10},
+				{Field: "TimeSeries.1.RequestCount", Value: 20},
+			},
+		},
+		{
+			fieldID: "ResponseTimes.*",
+			exp:     []metrics.Metric{},
+		},
+		{
+			fieldID: "StatusCodesDistribution.*",
+			exp: []metrics.Metric{
+				{Field: "StatusCodesDistribution.200", Value: 5},
+				{Field: "StatusCodesDistribution.404", Value: 2},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.fieldID, func(t *testing.T) {
+			got := agg.MetricsOf(metrics.Field(tc.fieldID))
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("exp %v, got %v", tc.exp, got)
+			}
+		})
+	}
+}
+
 func TestAggregate_MetricOf(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -93,6 +149,16 @@ func TestAggregate_MetricOf(t *testing.T) {
 			},
 			exp: 100 * time.Millisecond,
 		},
+		{
+			name:    "get metrics from percentiles",
+			fieldID: "ResponseTimes.P99_9",
+			agg: metrics.Aggregate{
+				ResponseTimes: timestats.TimeStats{
+					Percentiles: map[string]time.Duration{"P99": 90 * time.Millisecond, "P99_9": 120 * time.Millisecond},
+				},
+			},
+			exp: 120 * time.Millisecond,
+		},
 		{
 			name:    "get metrics from methods",
 			fieldID: "RequestSuccessCount",
@@ -102,6 +168,48 @@ func TestAggregate_MetricOf(t *testing.T) {
 			},
 			exp: 2,
 		},
+		{
+			name:    "get metrics from int field",
+			fieldID: "RequestCanceledCount",
+			agg:     metrics.Aggregate{RequestCanceledCount: 3},
+			exp:     3,
+		},
+		{
+			name:    "get metrics of reused connections",
+			fieldID: "ReusedConnectionsResponseTimes.Mean",
+			agg: metrics.Aggregate{
+				ReusedConnectionsResponseTimes: timestats.TimeStats{Mean: 20 * time.Millisecond},
+			},
+			exp: 20 * time.Millisecond,
+		},
+		{
+			name:    "get metrics of a phase",
+			fieldID: "Phases.TLS.Max",
+			agg: metrics.Aggregate{
+				Phases: metrics.PhasesAggregate{
+					TLS: timestats.TimeStats{Max: 50 * time.Millisecond},
+				},
+			},
+			exp: 50 * time.Millisecond,
+		},
+		{
+			name:    "get metrics from invalid responses",
+			fieldID: "InvalidResponsesDistribution.status",
+			agg: metrics.Aggregate{
+				InvalidResponsesDistribution: map[string]int{"status": 4},
+			},
+			exp: 4,
+		},
+		{
+			name:    "get metrics of a named request",
+			fieldID: "Requests.login.ResponseTimes.Max",
+			agg: metrics.Aggregate{
+				Requests: map[string]metrics.Aggregate{
+					"login": {ResponseTimes: timestats.TimeStats{Max: time.Second}},
+				},
+			},
+			exp: time.Second,
+		},
 		{
 			name:    "get metrics from int map",
 			fieldID: "StatusCodesDistribution.404",
@@ -110,6 +218,28 @@ func TestAggregate_MetricOf(t *testing.T) {
 			},
 			exp: 5,
 		},
+		{
+			name:    "get zero value for missing map key",
+			fieldID: "StatusCodesDistribution.500",
+			agg: metrics.Aggregate{
+				StatusCodesDistribution: map[int]int{200: 10},
+			},
+			exp: 0,
+		},
+		{
+			name:    "get zero value for out of range slice index",
+			fieldID: "TimeSeries.5.RequestCount",
+			agg: metrics.Aggregate{
+				TimeSeries: metrics.TimeSeries{{RequestCount: 10}},
+			},
+			exp: 0,
+		},
+		{
+			name:    "get zero value for nil slice",
+			fieldID: "ResponseTimes.Quartiles.1",
+			agg:     metrics.Aggregate{},
+			exp:     time.Duration(0),
+		},
 		{
 			name:    "get metrics from string map",
 			fieldID: "RequestEventTimes.FirstResponseByte.Mean",
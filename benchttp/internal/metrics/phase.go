@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics/timestats"
+	"github.com/benchttp/engine/benchttp/internal/recorder"
+)
+
+// PhasesAggregate is the common statistics of the durations
+// of each phase of the requests, computed from recorder.Record.Phases.
+// The statistics of a phase only include the records in which
+// it occurred, e.g. DNS, Connect and TLS exclude reused connections.
+type PhasesAggregate struct {
+	DNS      timestats.TimeStats
+	Connect  timestats.TimeStats
+	TLS      timestats.TimeStats
+	Write    timestats.TimeStats
+	Wait     timestats.TimeStats
+	Transfer timestats.TimeStats
+}
+
+// newPhasesAggregate computes the statistics of the phases
+// of the records.
+func newPhasesAggregate(records []recorder.Record, percentiles []float64) PhasesAggregate {
+	var dns, connect, tls, write, wait, transfer []time.Duration
+	appendNonZero := func(times []time.Duration, d time.Duration) []time.Duration {
+		if d > 0 {
+			return append(times, d)
+		}
+		return times
+	}
+
+	for _, rec := range records {
+		p := rec.Phases
+		dns = appendNonZero(dns, p.DNS)
+		connect = appendNonZero(connect, p.Connect)
+		tls = appendNonZero(tls, p.TLS)
+		write = appendNonZero(write, p.Write)
+		wait = appendNonZero(wait, p.Wait)
+		transfer = appendNonZero(transfer, p.Transfer)
+	}
+
+	return PhasesAggregate{
+		DNS:      timestats.New(dns, percentiles...),
+		Connect:  timestats.New(connect, percentiles...),
+		TLS:      timestats.New(tls, percentiles...),
+		Write:    timestats.New(write, percentiles...),
+		Wait:     timestats.New(wait, percentiles...),
+		Transfer: timestats.New(transfer, percentiles...),
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics/timestats"
+	"github.com/benchttp/engine/benchttp/internal/recorder"
+)
+
+// SizeStats is the common statistics of sizes in bytes.
+type SizeStats struct {
+	Min, Max, Mean, Median, StdDev int
+	Quartiles                      []int
+	Deciles                        []int
+	// Percentiles maps the key of each computed percentile to its
+	// value, as timestats.TimeStats.Percentiles.
+	Percentiles map[string]int `reflectpath:"inline"`
+}
+
+// newSizeStats computes the SizeStats of sizes, including the given
+// percentiles. The statistics are the ones of timestats.TimeStats,
+// one byte being handled as one nanosecond.
+func newSizeStats(sizes []int, percentiles []float64) SizeStats {
+	if len(sizes) == 0 {
+		return SizeStats{}
+	}
+
+	values := make([]time.Duration, len(sizes))
+	for i, size := range sizes {
+		values[i] = time.Duration(size)
+	}
+	stats := timestats.New(values, percentiles...)
+
+	percentileValues := make(map[string]int, len(stats.Percentiles))
+	for key, v := range stats.Percentiles {
+		percentileValues[key] = int(v)
+	}
+
+	return SizeStats{
+		Min:       int(stats.Min),
+		Max:       int(stats.Max),
+		Mean:      int(stats.Mean),
+		Median:    int(stats.Median),
+		StdDev:    int(stats.StdDev),
+		Quartiles: durationsToInts(stats.Quartiles),
+		Deciles:   durationsToInts(stats.Deciles),
+
+		Percentiles: percentileValues,
+	}
+}
+
+func durationsToInts(values []time.Duration) []int {
+	if values == nil {
+		return nil
+	}
+	ints := make([]int, len(values))
+	for i, v := range values {
+		ints[i] = int(v)
+	}
+	return ints
+}
+
+// recordingWindow returns the time span of the records, from the start
+// of the earliest one to the end of the latest one to end.
+func recordingWindow(records []recorder.Record) time.Duration {
+	if len(records) == 0 {
+		return 0
+	}
+	first, last := records[0].Start, records[0].Start
+	for _, rec := range records {
+		if rec.Start.Before(first) {
+			first = rec.Start
+		}
+		if end := rec.Start.Add(rec.Time); end.After(last) {
+			last = end
+		}
+	}
+	return last.Sub(first)
+}
+
+// computeThroughput sets the size and throughput metrics of agg
+// computed from records. It must be called once the request counts
+// of agg are computed.
+func computeThroughput(agg *Aggregate, records []recorder.Record, percentiles []float64) {
+	sizes := make([]int, 0, len(records))
+	for _, rec := range records {
+		agg.BytesReceived += rec.Bytes
+		agg.BytesSent += rec.BytesSent
+		if rec.Error == "" {
+			sizes = append(sizes, rec.Bytes)
+		}
+	}
+	agg.ResponseSizes = newSizeStats(sizes, percentiles)
+
+	agg.RecordingWindow = recordingWindow(records)
+	perSecond := func(n int) float64 {
+		if agg.RecordingWindow <= 0 {
+			return 0
+		}
+		return float64(n) / agg.RecordingWindow.Seconds()
+	}
+	agg.RequestsPerSecond = perSecond(agg.RequestCount())
+	agg.SuccessfulRequestsPerSecond = perSecond(agg.RequestSuccessCount())
+	agg.BytesReceivedPerSecond = perSecond(agg.BytesReceived)
+	agg.BytesSentPerSecond = perSecond(agg.BytesSent)
+}
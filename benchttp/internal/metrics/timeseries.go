@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/benchttp/engine/benchttp/internal/metrics/timestats"
+	"github.com/benchttp/engine/benchttp/internal/recorder"
+)
+
+const (
+	// DefaultTimeSeriesWindow is the width of the windows of a TimeSeries
+	// if none is specified.
+	DefaultTimeSeriesWindow = time.Second
+	// MinTimeSeriesWindow is the minimum width of the windows
+	// of a TimeSeries.
+	MinTimeSeriesWindow = 10 * time.Millisecond
+	// MaxTimeSeriesWindows is the maximum number of windows
+	// of a TimeSeries.
+	MaxTimeSeriesWindows = 100000
+)
+
+// TimeSeries is the sequence of the consecutive time windows of a run,
+// from the start of its first request to the start of its last one.
+type TimeSeries []TimeWindow
+
+// TimeWindow is an aggregate of metrics computed from the records
+// of the requests started in a same time window of the run.
+type TimeWindow struct {
+	// Start is the offset of the window from the start of the first
+	// request of the run.
+	Start time.Duration
+	// RequestCount is the count of requests started in the window,
+	// excluding canceled ones.
+	RequestCount int
+	// Throughput is the number of requests started per second
+	// in the window.
+	Throughput float64
+	// ErrorCount is the count of failing requests started in the window.
+	ErrorCount int
+	// ResponseTimes is the common statistics of the recorder.Record.Time
+	// of the requests started in the window.
+	ResponseTimes timestats.TimeStats
+}
+
+// NewTimeSeries computes the TimeSeries of records with windows of
+// the given width, or DefaultTimeSeriesWindow if width is not positive.
+// The width is raised to MinTimeSeriesWindow if it is lower, and widened
+// to a whole number of milliseconds if the run would span more than
+// MaxTimeSeriesWindows windows.
+// Windows without any request are included with zero values.
+// Their statistics include the given percentiles, or
+// timestats.DefaultPercentiles if none are given.
+func NewTimeSeries(records []recorder.Record, width time.Duration, percentiles ...float64) TimeSeries {
+	if width <= 0 {
+		width = DefaultTimeSeriesWindow
+	} else if width < MinTimeSeriesWindow {
+		width = MinTimeSeriesWindow
+	}
+
+	records, _ = excludeCanceled(records)
+	if len(records) == 0 {
+		return nil
+	}
+
+	origin, last := records[0].Start, records[0].Start
+	for _, rec := range records {
+		if rec.Start.Before(origin) {
+			origin = rec.Start
+		}
+		if rec.Start.After(last) {
+			last = rec.Start
+		}
+	}
+
+	span := last.Sub(origin)
+	if span/width >= MaxTimeSeriesWindows {
+		width = (span / (MaxTimeSeriesWindows - 1)).Truncate(time.Millisecond) + time.Millisecond
+	}
+
+	n := int(span/width) + 1
+	times := make([][]time.Duration, n)
+	series := make(TimeSeries, n)
+	for _, rec := range records {
+		i := int(rec.Start.Sub(origin) / width)
+		series[i].RequestCount++
+		if rec.Error != "" {
+			series[i].ErrorCount++
+		}
+		times[i] = append(times[i], rec.Time)
+	}
+
+	for i := range series {
+		series[i].Start = time.Duration(i) * width
+		series[i].Throughput = float64(series[i].RequestCount) / width.Seconds()
+		series[i].ResponseTimes = timestats.New(times[i], percentiles...)
+	}
+
+	return series
+}
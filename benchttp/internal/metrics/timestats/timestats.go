@@ -3,6 +3,8 @@ package timestats
 import (
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,18 +13,35 @@ const (
 	numQuartile = 4
 )
 
+// DefaultPercentiles are the percentiles computed by New
+// if none are specified.
+var DefaultPercentiles = []float64{50, 90, 95, 99, 99.9}
+
 type TimeStats struct {
 	Min, Max, Mean, Median, StdDev time.Duration
 	Quartiles                      []time.Duration
 	Deciles                        []time.Duration
+	// Percentiles maps the key of each computed percentile, e.g. "P99"
+	// or "P99_9" (see PercentileKey), to its value. The keys are
+	// addressable as fields of TimeStats, e.g. "ResponseTimes.P99".
+	Percentiles map[string]time.Duration `reflectpath:"inline"`
 }
 
-func New(times []time.Duration) TimeStats {
+// New computes the TimeStats of times, including the given percentiles,
+// or DefaultPercentiles if none are given. All quantiles, including
+// Median, Quartiles and Deciles, are computed with the linear
+// interpolation between the closest ranks (type 7 of Hyndman and Fan,
+// the default of R and NumPy).
+func New(times []time.Duration, percentiles ...float64) TimeStats {
 	n := len(times)
 	if n == 0 {
 		return TimeStats{}
 	}
 
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+
 	// Measures computing functions works on sorted data.
 	// Sort once and compute upon the result.
 	sort.Sort(byFastest(times))
@@ -32,16 +51,23 @@ func New(times []time.Duration) TimeStats {
 	mean := computeMean(sum, n)
 
 	return TimeStats{
-		Min:       times[0],
-		Max:       times[len(times)-1],
-		Mean:      mean,
-		Median:    computeMedian(times),
-		StdDev:    computeStdDev(times, mean),
-		Quartiles: computeQuartiles(times),
-		Deciles:   computeDeciles(times),
+		Min:         times[0],
+		Max:         times[len(times)-1],
+		Mean:        mean,
+		Median:      computePercentile(times, 50),
+		StdDev:      computeStdDev(times, mean),
+		Quartiles:   computeQuantiles(times, numQuartile),
+		Deciles:     computeQuantiles(times, numDecile),
+		Percentiles: computePercentiles(times, percentiles),
 	}
 }
 
+// PercentileKey returns the key of the percentile p in
+// TimeStats.Percentiles, e.g. "P95" for 95 and "P99_9" for 99.9.
+func PercentileKey(p float64) string {
+	return "P" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+}
+
 func computeSum(values []time.Duration) time.Duration {
 	var sum time.Duration
 	for _, time := range values {
@@ -54,17 +80,6 @@ func computeMean(sum time.Duration, length int) time.Duration {
 	return sum / time.Duration(length)
 }
 
-func computeMedian(sorted []time.Duration) time.Duration {
-	n := len(sorted)
-	mid := n / 2
-	if odd := n&1 == 1; odd {
-		return sorted[mid]
-	}
-	himid := mid
-	lomid := himid - 1
-	return computeMean(sorted[lomid]+sorted[himid], 2)
-}
-
 func computeStdDev(values []time.Duration, mean time.Duration) time.Duration {
 	sum := time.Duration(0)
 	for _, v := range values {
@@ -75,32 +90,36 @@ func computeStdDev(values []time.Duration, mean time.Duration) time.Duration {
 	return time.Duration(math.Sqrt(float64(sum / time.Duration(n))))
 }
 
-func computeDeciles(sorted []time.Duration) []time.Duration {
-	if len(sorted) < numDecile {
-		return nil
+// computeQuantiles returns the nQuantiles quantiles of sorted, the last
+// one being its maximum, e.g. the 25th, 50th, 75th and 100th percentiles
+// for 4 quantiles.
+func computeQuantiles(sorted []time.Duration, nQuantiles int) []time.Duration {
+	quantiles := make([]time.Duration, nQuantiles)
+	for i := range quantiles {
+		quantiles[i] = computePercentile(sorted, float64(i+1)*100/float64(nQuantiles))
 	}
-	return computeQuantiles(sorted, numDecile)
+	return quantiles
 }
 
-func computeQuartiles(sorted []time.Duration) []time.Duration {
-	if len(sorted) < numQuartile {
-		return nil
+func computePercentiles(sorted []time.Duration, percentiles []float64) map[string]time.Duration {
+	values := make(map[string]time.Duration, len(percentiles))
+	for _, p := range percentiles {
+		values[PercentileKey(p)] = computePercentile(sorted, p)
 	}
-	return computeQuantiles(sorted, numQuartile)
+	return values
 }
 
-func computeQuantiles(sorted []time.Duration, nQuantiles int) []time.Duration {
-	n := len(sorted)
-	step := (n + 1) / nQuantiles
-
-	quantiles := make([]time.Duration, nQuantiles)
-	for i := 0; i < nQuantiles; i++ {
-		qtlIndex := (i + 1) * step
-		maxIndex := n - 1
-		if qtlIndex > maxIndex {
-			qtlIndex = maxIndex
-		}
-		quantiles[i] = sorted[qtlIndex]
+// computePercentile returns the p-th percentile of sorted, linearly
+// interpolated between the closest ranks. sorted must not be empty.
+func computePercentile(sorted []time.Duration, p float64) time.Duration {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
 	}
-	return quantiles
+	if lo < 0 {
+		return sorted[0]
+	}
+	frac := rank - float64(lo)
+	return sorted[lo] + time.Duration(math.Round(frac*float64(sorted[lo+1]-sorted[lo])))
 }
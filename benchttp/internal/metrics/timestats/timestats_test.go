@@ -1,6 +1,7 @@
 package timestats_test
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -15,7 +16,7 @@ func TestCompute(t *testing.T) {
 			Mean:      230,
 			Median:    200,
 			StdDev:    110,
-			Deciles:   []time.Duration{100, 100, 200, 200, 200, 300, 300, 400, 400, 400},
+			Deciles:   []time.Duration{100, 100, 170, 200, 200, 240, 300, 320, 400, 400},
 			Quartiles: []time.Duration{100, 200, 300, 400},
 		}
 
@@ -64,18 +65,76 @@ func TestCompute(t *testing.T) {
 		data := []time.Duration{100, 300}
 		got := timestats.New(data)
 
-		if got.Deciles != nil {
-			t.Errorf("deciles: want nil, got %v", got.Deciles)
+		wantDeciles := []time.Duration{120, 140, 160, 180, 200, 220, 240, 260, 280, 300}
+		if !reflect.DeepEqual(got.Deciles, wantDeciles) {
+			t.Errorf("deciles: want %v, got %v", wantDeciles, got.Deciles)
 		}
 
-		if got.Quartiles != nil {
-			t.Errorf("quartiles: want nil, got %v", got.Quartiles)
+		wantQuartiles := []time.Duration{150, 200, 250, 300}
+		if !reflect.DeepEqual(got.Quartiles, wantQuartiles) {
+			t.Errorf("quartiles: want %v, got %v", wantQuartiles, got.Quartiles)
 		}
 
 		if got.Median != 200 {
 			t.Errorf("median: want 200ns, got %v", got.Median)
 		}
 	})
+
+	t.Run("percentiles", func(t *testing.T) {
+		data := make([]time.Duration, 1000)
+		for i := range data {
+			data[i] = time.Duration(i + 1)
+		}
+
+		for _, tc := range []struct {
+			percentiles []float64
+			want        map[string]time.Duration
+		}{
+			{
+				percentiles: nil,
+				want:        map[string]time.Duration{"P50": 501, "P90": 900, "P95": 950, "P99": 990, "P99_9": 999},
+			},
+			{
+				percentiles: []float64{0, 75, 100},
+				want:        map[string]time.Duration{"P0": 1, "P75": 750, "P100": 1000},
+			},
+		} {
+			got := timestats.New(data, tc.percentiles...)
+			if !reflect.DeepEqual(got.Percentiles, tc.want) {
+				t.Errorf("%v: want %v, got %v", tc.percentiles, tc.want, got.Percentiles)
+			}
+		}
+	})
+
+	t.Run("median and quantiles match percentiles", func(t *testing.T) {
+		data := []time.Duration{700, 100, 400, 900, 200, 300, 1000, 800, 500, 600, 150}
+		got := timestats.New(data, 10, 25, 50, 75, 90)
+
+		if got.Median != got.Percentiles["P50"] {
+			t.Errorf("median: want %v, got %v", got.Percentiles["P50"], got.Median)
+		}
+		for i, key := range []string{"P25", "P50", "P75"} {
+			if got.Quartiles[i] != got.Percentiles[key] {
+				t.Errorf("quartile %d: want %v, got %v", i+1, got.Percentiles[key], got.Quartiles[i])
+			}
+		}
+		for _, d := range []struct {
+			index int
+			key   string
+		}{{0, "P10"}, {4, "P50"}, {8, "P90"}} {
+			if got.Deciles[d.index] != got.Percentiles[d.key] {
+				t.Errorf("decile %d: want %v, got %v", d.index+1, got.Percentiles[d.key], got.Deciles[d.index])
+			}
+		}
+	})
+}
+
+func TestPercentileKey(t *testing.T) {
+	for p, want := range map[float64]string{50: "P50", 99.9: "P99_9", 99.99: "P99_99", 0.5: "P0_5"} {
+		if got := timestats.PercentileKey(p); got != want {
+			t.Errorf("%v: want %q, got %q", p, want, got)
+		}
+	}
 }
 
 // approxEqual returns true if val is equal to target with a margin of error.
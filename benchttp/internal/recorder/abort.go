@@ -0,0 +1,224 @@
+package recorder
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AbortCondition is a condition on the latest results of a run that stops
+// the run early when it is met, e.g. "failure rate > 20% over the last 100
+// requests" or "p95 > 5s over the last 10s".
+//
+// The results considered form the window of the condition: the last
+// WindowRequests requests, the requests done within the last WindowDuration,
+// or the intersection of both if both are set. The condition is only
+// evaluated once its window is complete, i.e. once WindowRequests requests
+// are done and WindowDuration is elapsed since the start of the run.
+// Canceled and warm-up requests are ignored.
+type AbortCondition struct {
+	// FailureRate, if set, is the maximum ratio of failed requests
+	// in the window, in ]0, 1]. Responses failing Config.Checks
+	// count as failures.
+	FailureRate float64
+	// Latency, if set, is the maximum response time at Percentile
+	// in the window.
+	Latency time.Duration
+	// Percentile is the percentile of the response times compared
+	// to Latency, in ]0, 100].
+	Percentile int
+
+	WindowRequests int
+	WindowDuration time.Duration
+}
+
+// String returns a human-readable representation of c.
+func (c AbortCondition) String() string {
+	var thresholds []string
+	if c.FailureRate > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("failure rate > %s", formatRatio(c.FailureRate)))
+	}
+	if c.Latency > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("p%d > %v", c.Percentile, c.Latency))
+	}
+
+	var window []string
+	if c.WindowRequests > 0 {
+		window = append(window, fmt.Sprintf("%d requests", c.WindowRequests))
+	}
+	if c.WindowDuration > 0 {
+		window = append(window, c.WindowDuration.String())
+	}
+
+	return fmt.Sprintf(
+		"%s over the last %s",
+		strings.Join(thresholds, " or "), strings.Join(window, " and "),
+	)
+}
+
+// Abort describes the early stop of a run caused by an AbortCondition.
+type Abort struct {
+	// Condition is the AbortCondition that was met.
+	Condition AbortCondition
+	// Reason describes the values that met the condition.
+	Reason string
+}
+
+// abortSample is the subset of a Record needed to evaluate
+// abort conditions, along with the time it was recorded.
+type abortSample struct {
+	at   time.Time
+	time time.Duration
+	// failed is true if the request failed or its response is invalid.
+	failed bool
+}
+
+// abortMonitor evaluates a set of abort conditions against the results
+// of a run. It is safe for concurrent use.
+type abortMonitor struct {
+	mu      sync.Mutex
+	start   time.Time
+	windows []*abortWindow
+}
+
+// newAbortMonitor returns an abortMonitor evaluating conditions
+// against the results of a run started at start.
+func newAbortMonitor(conditions []AbortCondition, start time.Time) *abortMonitor {
+	m := &abortMonitor{start: start}
+	for _, c := range conditions {
+		m.windows = append(m.windows, &abortWindow{condition: c})
+	}
+	return m
+}
+
+// add appends rec to the monitored results, then evaluates the conditions.
+// It returns a non-nil *Abort if any of the conditions is met.
+func (m *abortMonitor) add(rec Record, now time.Time) *Abort {
+	if rec.Canceled || rec.Warmup {
+		return nil
+	}
+
+	s := abortSample{
+		at:     now,
+		time:   rec.Time,
+		failed: rec.Error != "" || rec.InvalidReason != "",
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var abort *Abort
+	for _, w := range m.windows {
+		w.push(s)
+		w.prune(now)
+		if abort != nil {
+			continue
+		}
+		if reason, met := w.evaluate(m.start, now); met {
+			abort = &Abort{Condition: w.condition, Reason: reason}
+		}
+	}
+	return abort
+}
+
+// abortWindow holds the samples in the window of an AbortCondition,
+// along with the aggregates needed to evaluate it, which are updated
+// as samples enter and leave the window.
+type abortWindow struct {
+	condition AbortCondition
+	// samples are the samples in the window, oldest first.
+	samples   []abortSample
+	numFailed int
+	// times are the response times of samples in ascending order,
+	// only kept if condition.Latency is set.
+	times []time.Duration
+}
+
+// push appends s to the window.
+func (w *abortWindow) push(s abortSample) {
+	w.samples = append(w.samples, s)
+	if s.failed {
+		w.numFailed++
+	}
+	if w.condition.Latency > 0 {
+		i := sort.Search(len(w.times), func(i int) bool { return w.times[i] >= s.time })
+		w.times = append(w.times, 0)
+		copy(w.times[i+1:], w.times[i:])
+		w.times[i] = s.time
+	}
+}
+
+// prune removes the oldest samples that are outside the window.
+func (w *abortWindow) prune(now time.Time) {
+	n := 0
+	for n < len(w.samples) && !inWindow(w.condition, len(w.samples)-n, now.Sub(w.samples[n].at)) {
+		s := w.samples[n]
+		if s.failed {
+			w.numFailed--
+		}
+		if w.condition.Latency > 0 {
+			i := sort.Search(len(w.times), func(i int) bool { return w.times[i] >= s.time })
+			w.times = append(w.times[:i], w.times[i+1:]...)
+		}
+		n++
+	}
+	w.samples = w.samples[n:]
+}
+
+// evaluate returns true and a description of the values that met
+// the condition of w if it is met, for a run started at start.
+func (w *abortWindow) evaluate(start, now time.Time) (string, bool) {
+	c := w.condition
+	if c.WindowDuration > 0 && now.Sub(start) < c.WindowDuration {
+		return "", false
+	}
+
+	n := len(w.samples)
+	if n == 0 || n < c.WindowRequests {
+		return "", false
+	}
+
+	if c.FailureRate > 0 {
+		if rate := float64(w.numFailed) / float64(n); rate > c.FailureRate {
+			return fmt.Sprintf("failure rate %s > %s", formatRatio(rate), formatRatio(c.FailureRate)), true
+		}
+	}
+
+	if c.Latency > 0 {
+		if p := percentile(w.times, c.Percentile); p > c.Latency {
+			return fmt.Sprintf("p%d %v > %v", c.Percentile, p, c.Latency), true
+		}
+	}
+
+	return "", false
+}
+
+// inWindow returns true if a sample that is the rank-th latest one
+// and was recorded age ago is in the window of c.
+func inWindow(c AbortCondition, rank int, age time.Duration) bool {
+	if c.WindowRequests > 0 && rank > c.WindowRequests {
+		return false
+	}
+	if c.WindowDuration > 0 && age >= c.WindowDuration {
+		return false
+	}
+	return true
+}
+
+// percentile returns the p-th percentile of the sorted durations times
+// using the nearest-rank method. times must not be empty.
+func percentile(times []time.Duration, p int) time.Duration {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(times))))
+	if rank < 1 {
+		rank = 1
+	}
+	return times[rank-1]
+}
+
+// formatRatio returns ratio formatted as a percentage, e.g. "20%".
+func formatRatio(ratio float64) string {
+	return fmt.Sprintf("%g%%", math.Round(ratio*10000)/100)
+}
@@ -0,0 +1,162 @@
+package recorder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAbortMonitor(t *testing.T) {
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	t.Run("failure rate over the last requests", func(t *testing.T) {
+		m := newAbortMonitor([]AbortCondition{{FailureRate: 0.5, WindowRequests: 4}}, start)
+
+		for i, tc := range []struct {
+			rec      Record
+			expAbort bool
+		}{
+			{rec: Record{Error: "x"}},                 // window not complete
+			{rec: Record{Error: "x"}},                 // window not complete
+			{rec: Record{Canceled: true}},             // ignored
+			{rec: Record{}},                           // window not complete
+			{rec: Record{}},                           // 2/4 failed
+			{rec: Record{}},                           // 1/4 failed
+			{rec: Record{Error: "x"}},                 // 1/4 failed
+			{rec: Record{Error: "x"}},                 // 2/4 failed
+			{rec: Record{Error: "x"}, expAbort: true}, // 3/4 failed
+		} {
+			abort := m.add(tc.rec, at(time.Duration(i)*time.Millisecond))
+			if gotAbort := abort != nil; gotAbort != tc.expAbort {
+				t.Fatalf("record %d: exp abort %v, got %v", i, tc.expAbort, abort)
+			}
+			if abort != nil && abort.Reason != "failure rate 75% > 50%" {
+				t.Errorf("unexpected reason: %q", abort.Reason)
+			}
+		}
+
+		if n := len(m.windows[0].samples); n != 4 {
+			t.Errorf("exp obsolete samples to be pruned, got %d samples", n)
+		}
+	})
+
+	t.Run("failure rate including invalid responses", func(t *testing.T) {
+		m := newAbortMonitor([]AbortCondition{{FailureRate: 0.5, WindowRequests: 2}}, start)
+
+		if abort := m.add(Record{InvalidReason: "status"}, at(0)); abort != nil {
+			t.Fatalf("exp no abort before window is complete, got %v", abort)
+		}
+		abort := m.add(Record{Error: "x"}, at(time.Millisecond))
+		if abort == nil {
+			t.Fatal("exp abort, got nil")
+		}
+		if abort.Reason != "failure rate 100% > 50%" {
+			t.Errorf("unexpected reason: %q", abort.Reason)
+		}
+	})
+
+	t.Run("latency percentile over the last duration", func(t *testing.T) {
+		m := newAbortMonitor([]AbortCondition{{
+			Latency:        100 * time.Millisecond,
+			Percentile:     95,
+			WindowDuration: time.Second,
+		}}, start)
+
+		// window not complete
+		if abort := m.add(Record{Time: 500 * time.Millisecond}, at(100*time.Millisecond)); abort != nil {
+			t.Fatalf("exp no abort before window is complete, got %v", abort)
+		}
+		// first sample expired, p95 = 10ms
+		if abort := m.add(Record{Time: 10 * time.Millisecond}, at(1200*time.Millisecond)); abort != nil {
+			t.Fatalf("exp no abort, got %v", abort)
+		}
+		// p95 = 500ms
+		abort := m.add(Record{Time: 500 * time.Millisecond}, at(1300*time.Millisecond))
+		if abort == nil {
+			t.Fatal("exp abort, got nil")
+		}
+		if abort.Reason != "p95 500ms > 100ms" {
+			t.Errorf("unexpected reason: %q", abort.Reason)
+		}
+		if n := len(m.windows[0].samples); n != 2 {
+			t.Errorf("exp expired samples to be pruned, got %d samples", n)
+		}
+	})
+}
+
+func TestAbortWindow(t *testing.T) {
+	start := time.Now()
+	w := abortWindow{condition: AbortCondition{
+		Latency:        20 * time.Millisecond,
+		Percentile:     50,
+		WindowRequests: 3,
+	}}
+
+	for i, tc := range []struct {
+		time     time.Duration
+		failed   bool
+		expTimes []time.Duration
+		expFails int
+	}{
+		{time: 30, failed: true, expTimes: []time.Duration{30}, expFails: 1},
+		{time: 10, expTimes: []time.Duration{10, 30}, expFails: 1},
+		{time: 30, expTimes: []time.Duration{10, 30, 30}, expFails: 1},
+		{time: 20, expTimes: []time.Duration{10, 20, 30}, expFails: 0},
+		{time: 10, failed: true, expTimes: []time.Duration{10, 20, 30}, expFails: 1},
+	} {
+		now := start.Add(time.Duration(i) * time.Millisecond)
+		w.push(abortSample{at: now, time: tc.time, failed: tc.failed})
+		w.prune(now)
+
+		if !reflect.DeepEqual(w.times, tc.expTimes) {
+			t.Errorf("sample %d: exp sorted times %v, got %v", i, tc.expTimes, w.times)
+		}
+		if w.numFailed != tc.expFails {
+			t.Errorf("sample %d: exp %d failures, got %d", i, tc.expFails, w.numFailed)
+		}
+	}
+}
+
+func TestAbortCondition_String(t *testing.T) {
+	c := AbortCondition{
+		FailureRate:    0.2,
+		Latency:        5 * time.Second,
+		Percentile:     95,
+		WindowRequests: 100,
+		WindowDuration: 10 * time.Second,
+	}
+
+	exp := "failure rate > 20% or p95 > 5s over the last 100 requests and 10s"
+	if got := c.String(); got != exp {
+		t.Errorf("exp %q, got %q", exp, got)
+	}
+}
+
+func TestRecorder_Record_abort(t *testing.T) {
+	r := withErrTransport(New(Config{
+		Requests:        100,
+		Concurrency:     1,
+		RequestTimeout:  1 * time.Second,
+		GlobalTimeout:   3 * time.Second,
+		AbortConditions: []AbortCondition{{FailureRate: 0.5, WindowRequests: 10}},
+	}))
+
+	recs, err := r.Record(context.Background(), validRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(recs) != 10 {
+		t.Errorf("unexpected records length: exp 10, got %d", len(recs))
+	}
+
+	if r.Aborted() == nil {
+		t.Error("exp non-nil Abort, got nil")
+	}
+
+	if status := r.Progress().Status(); status != StatusAborted {
+		t.Errorf("unexpected status: exp %s, got %s", StatusAborted, status)
+	}
+}
@@ -0,0 +1,234 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthType is the authentication scheme of an Auth.
+type AuthType string
+
+const (
+	// AuthBasic authenticates requests with Auth.Username
+	// and Auth.Password (RFC 7617).
+	AuthBasic AuthType = "basic"
+	// AuthBearer authenticates requests with the static token Auth.Token.
+	AuthBearer AuthType = "bearer"
+	// AuthOAuth2 authenticates requests with a bearer token obtained
+	// from Auth.TokenURL with the OAuth2 client credentials grant
+	// (RFC 6749, section 4.4).
+	AuthOAuth2 AuthType = "oauth2"
+)
+
+// Auth determines how requests are authenticated. Requests that already
+// have an Authorization header are sent unchanged. A zero Auth
+// does not authenticate requests.
+type Auth struct {
+	Type AuthType
+
+	// Username and Password are the credentials of AuthBasic.
+	Username string
+	Password string
+
+	// Token is the token of AuthBearer.
+	Token string
+
+	// TokenURL, ClientID, ClientSecret and Scopes are the parameters
+	// of AuthOAuth2.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// Validate returns a non-nil error if a cannot be used
+// to authenticate requests.
+func (a Auth) Validate() error {
+	switch a.Type {
+	case "":
+	case AuthBasic:
+		if a.Username == "" {
+			return errors.New("username: want non-empty for type basic")
+		}
+	case AuthBearer:
+		if a.Token == "" {
+			return errors.New("token: want non-empty for type bearer")
+		}
+	case AuthOAuth2:
+		if u, err := url.ParseRequestURI(a.TokenURL); err != nil || u.Host == "" {
+			return fmt.Errorf("tokenURL (%q): want an absolute URL for type oauth2", a.TokenURL)
+		}
+		if a.ClientID == "" {
+			return errors.New("clientID: want non-empty for type oauth2")
+		}
+	default:
+		return fmt.Errorf(
+			"unknown type %q: want %q, %q or %q",
+			a.Type, AuthBasic, AuthBearer, AuthOAuth2,
+		)
+	}
+	return nil
+}
+
+// oauth2ExpiryMargin is the maximum duration before the expiry
+// of an OAuth2 token from which it is refreshed, so that requests
+// in flight do not use an expired token.
+const oauth2ExpiryMargin = 10 * time.Second
+
+// authenticator sets the credentials of the requests as defined
+// by an Auth. OAuth2 tokens are fetched with their own client, so that
+// the time spent fetching them is not recorded, and cached until they
+// expire or are rejected. A nil *authenticator does not authenticate
+// requests.
+type authenticator struct {
+	auth   Auth
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if the token does not expire
+}
+
+// newAuthenticator returns an authenticator for a, fetching tokens
+// with the given timeout. It returns nil if a is zero.
+func newAuthenticator(a Auth, timeout time.Duration) *authenticator {
+	if a.Type == "" {
+		return nil
+	}
+	return &authenticator{
+		auth:   a,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// prepare obtains the credentials required before sending requests,
+// i.e. fetches the OAuth2 token.
+func (a *authenticator) prepare(ctx context.Context) error {
+	if a == nil || a.auth.Type != AuthOAuth2 {
+		return nil
+	}
+	_, err := a.oauth2Token(ctx)
+	return err
+}
+
+// authorize sets the Authorization header of req, unless it is already
+// set. It is safe for concurrent use.
+func (a *authenticator) authorize(ctx context.Context, req *http.Request) error {
+	if a == nil || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	switch a.auth.Type {
+	case AuthBasic:
+		req.SetBasicAuth(a.auth.Username, a.auth.Password)
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.auth.Token)
+	case AuthOAuth2:
+		token, err := a.oauth2Token(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// reject discards the OAuth2 token of a request that was answered
+// with 401 Unauthorized, if it is the cached one, so that the next
+// call to authorize fetches a new token. It returns true if the request
+// can be sent again with a new token.
+func (a *authenticator) reject(req *http.Request) bool {
+	if a == nil || a.auth.Type != AuthOAuth2 {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if req.Header.Get("Authorization") == "Bearer "+a.token {
+		a.token = ""
+	}
+	return true
+}
+
+// oauth2Token returns the cached OAuth2 token, or fetches a new one
+// if there is none or it is about to expire.
+func (a *authenticator) oauth2Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.expiry.IsZero() || time.Now().Before(a.expiry)) {
+		return a.token, nil
+	}
+
+	token, expiresIn, err := a.fetchOAuth2Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	a.token = token
+	a.expiry = time.Time{}
+	if expiresIn > 0 {
+		margin := expiresIn / 10
+		if margin > oauth2ExpiryMargin {
+			margin = oauth2ExpiryMargin
+		}
+		a.expiry = time.Now().Add(expiresIn - margin)
+	}
+	return token, nil
+}
+
+// fetchOAuth2Token requests a token from the token URL with the client
+// credentials grant. It returns the token and its lifetime, or 0
+// if unspecified.
+func (a *authenticator) fetchOAuth2Token(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, "POST", a.auth.TokenURL, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.auth.ClientID), url.QueryEscape(a.auth.ClientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token request: %s: %s", resp.Status, body)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("token response: %s", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("token response: missing access_token")
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
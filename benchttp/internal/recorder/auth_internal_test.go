@@ -0,0 +1,257 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAuth_Validate(t *testing.T) {
+	t.Run("return nil for valid auths", func(t *testing.T) {
+		for _, a := range []Auth{
+			{},
+			{Type: AuthBasic, Username: "jdoe"},
+			{Type: AuthBearer, Token: "abc"},
+			{Type: AuthOAuth2, TokenURL: "https://a.b/token", ClientID: "id"},
+		} {
+			if err := a.Validate(); err != nil {
+				t.Errorf("unexpected error for %+v: %v", a, err)
+			}
+		}
+	})
+
+	t.Run("return an error for invalid auths", func(t *testing.T) {
+		for _, a := range []Auth{
+			{Type: "digest"},
+			{Type: AuthBasic},
+			{Type: AuthBearer},
+			{Type: AuthOAuth2, TokenURL: "/token", ClientID: "id"},
+			{Type: AuthOAuth2, TokenURL: "https://a.b/token"},
+		} {
+			if err := a.Validate(); err == nil {
+				t.Errorf("exp error for %+v, got nil", a)
+			}
+		}
+	})
+}
+
+func TestAuthenticator_authorize(t *testing.T) {
+	testcases := []struct {
+		label   string
+		auth    Auth
+		header  string
+		expAuth string
+	}{
+		{
+			label:   "basic",
+			auth:    Auth{Type: AuthBasic, Username: "jdoe", Password: "secret"},
+			expAuth: "Basic amRvZTpzZWNyZXQ=",
+		},
+		{
+			label:   "bearer",
+			auth:    Auth{Type: AuthBearer, Token: "abc"},
+			expAuth: "Bearer abc",
+		},
+		{
+			label:   "keep explicit authorization",
+			auth:    Auth{Type: AuthBearer, Token: "abc"},
+			header:  "Bearer {{.token}}",
+			expAuth: "Bearer {{.token}}",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			req := mustNewRequest("GET", validURI)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			a := newAuthenticator(tc.auth, time.Second)
+			if err := a.authorize(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := req.Header.Get("Authorization"); got != tc.expAuth {
+				t.Errorf("exp Authorization %q, got %q", tc.expAuth, got)
+			}
+		})
+	}
+
+	t.Run("nil authenticator does not authorize", func(t *testing.T) {
+		req := mustNewRequest("GET", validURI)
+		if err := newAuthenticator(Auth{}, time.Second).authorize(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := req.Header.Get("Authorization"); got != "" {
+			t.Errorf("exp no Authorization, got %q", got)
+		}
+	})
+}
+
+func TestAuthenticator_oauth2Token(t *testing.T) {
+	srv, tokens := newTokenServer(t, 3600)
+
+	a := newAuthenticator(Auth{
+		Type:         AuthOAuth2,
+		TokenURL:     srv.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}, time.Second)
+
+	mustToken := func(exp string) {
+		t.Helper()
+		got, err := a.oauth2Token(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != exp {
+			t.Errorf("exp token %q, got %q", exp, got)
+		}
+	}
+
+	mustToken("token-1")
+	mustToken("token-1") // cached
+
+	a.expiry = time.Now().Add(-time.Second)
+	mustToken("token-2") // expired
+
+	req := mustNewRequest("GET", validURI)
+	req.Header.Set("Authorization", "Bearer token-2")
+	if !a.reject(req) {
+		t.Error("exp rejected oauth2 token to be renewable")
+	}
+	mustToken("token-3") // rejected
+
+	if got := tokens.count(); got != 3 {
+		t.Errorf("exp 3 token requests, got %d", got)
+	}
+}
+
+func TestRecorder_Record_oauth2(t *testing.T) {
+	t.Run("refresh the token on 401", func(t *testing.T) {
+		srv, tokens := newTokenServer(t, 0)
+
+		// The API only accepts the latest token and revokes it
+		// every 3 requests.
+		var (
+			mu       sync.Mutex
+			accepted int
+		)
+		r := withHandlerTransport(New(Config{
+			Requests:       6,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Auth:           Auth{Type: AuthOAuth2, TokenURL: srv.URL, ClientID: "id"},
+		}), http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !tokens.valid(req.Header.Get("Authorization")) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if accepted++; accepted%3 == 0 {
+				tokens.revoke()
+			}
+		}))
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", validURI))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(records) != 6 {
+			t.Fatalf("exp 6 records, got %d", len(records))
+		}
+		for i, rec := range records {
+			if rec.Code != http.StatusOK {
+				t.Errorf("records[%d]: exp code 200, got %d", i, rec.Code)
+			}
+		}
+		// Initial token, then a token after each revocation
+		// (the ping is sent before the first revocation).
+		if got := tokens.count(); got != 3 {
+			t.Errorf("exp 3 token requests, got %d", got)
+		}
+	})
+
+	t.Run("return ErrAuthentication if the token cannot be fetched", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer srv.Close()
+
+		r := withNoopTransport(New(Config{
+			Requests:       1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Auth:           Auth{Type: AuthOAuth2, TokenURL: srv.URL, ClientID: "id"},
+		}))
+
+		_, err := r.Record(context.Background(), mustNewRequest("GET", validURI))
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("exp ErrAuthentication, got %v", err)
+		}
+	})
+}
+
+// helpers
+
+type tokenCounter struct {
+	mu      sync.Mutex
+	n       int
+	revoked bool
+}
+
+func (c *tokenCounter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// valid returns true if authorization holds the latest token
+// and it is not revoked.
+func (c *tokenCounter) valid(authorization string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.revoked && authorization == fmt.Sprintf("Bearer token-%d", c.n)
+}
+
+func (c *tokenCounter) revoke() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked = true
+}
+
+// newTokenServer returns a server issuing the OAuth2 tokens
+// token-1, token-2, etc., expiring in expiresIn seconds.
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *tokenCounter) {
+	t.Helper()
+	tokens := &tokenCounter{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokens.mu.Lock()
+		tokens.n++
+		tokens.revoked = false
+		n := tokens.n
+		tokens.mu.Unlock()
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, tokens
+}
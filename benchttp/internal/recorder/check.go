@@ -0,0 +1,207 @@
+package recorder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CheckType is the kind of validation performed by a Check.
+type CheckType string
+
+const (
+	// CheckStatus checks the status code of the response against
+	// a comma-separated list of codes ("200"), classes ("2xx")
+	// or inclusive ranges ("200-299"), e.g. "200,204,3xx".
+	CheckStatus CheckType = "status"
+	// CheckHeader checks the response has the header Expression.
+	// If Value is set, the header value must match the regular
+	// expression Value.
+	CheckHeader CheckType = "header"
+	// CheckBodyRegex checks the response body matches the regular
+	// expression Expression.
+	CheckBodyRegex CheckType = "bodyRegex"
+	// CheckJSONEquals checks the value at the JSON pointer (RFC 6901)
+	// Expression in the response body equals Value. String values are
+	// compared as is, other values JSON-encoded, e.g. "true" or "42".
+	CheckJSONEquals CheckType = "jsonEquals"
+	// CheckJSONExists checks the JSON pointer (RFC 6901) Expression
+	// resolves to a value in the response body.
+	CheckJSONExists CheckType = "jsonExists"
+	// CheckMaxBodySize checks the response body size is at most
+	// Expression bytes.
+	CheckMaxBodySize CheckType = "maxBodySize"
+)
+
+// Check is a validation rule of the responses. A response failing
+// a check is recorded as invalid with the name of the check as reason:
+// its request did not fail, but its response is not the expected one.
+type Check struct {
+	// Name is the reason of the invalid responses failing the check.
+	// Defaults to Type.
+	Name       string
+	Type       CheckType
+	Expression string
+	Value      string
+
+	// statusRanges, rgx and maxSize are the compiled forms
+	// of Expression and Value, set by compile.
+	statusRanges [][2]int
+	rgx          *regexp.Regexp
+	maxSize      int
+}
+
+// reason returns the reason of the responses failing c.
+func (c Check) reason() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return string(c.Type)
+}
+
+// Validate returns a non-nil error if c cannot be used to check responses.
+func (c Check) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+// compile returns a copy of c with its expressions compiled, or a non-nil
+// error if c cannot be used to check responses. Only compiled checks
+// can check responses.
+func (c Check) compile() (Check, error) {
+	var err error
+	switch c.Type {
+	case CheckStatus:
+		c.statusRanges, err = parseStatusRanges(c.Expression)
+	case CheckHeader:
+		if c.Expression == "" {
+			return c, errors.New("empty header name")
+		}
+		c.rgx, err = regexp.Compile(c.Value)
+	case CheckBodyRegex:
+		c.rgx, err = regexp.Compile(c.Expression)
+	case CheckJSONEquals, CheckJSONExists:
+		if c.Expression != "" && !strings.HasPrefix(c.Expression, "/") {
+			return c, fmt.Errorf("invalid JSON pointer %q: want leading /", c.Expression)
+		}
+	case CheckMaxBodySize:
+		n, errAtoi := strconv.Atoi(c.Expression)
+		if errAtoi != nil || n < 0 {
+			return c, fmt.Errorf("invalid size %q: want a number of bytes >= 0", c.Expression)
+		}
+		c.maxSize = n
+	default:
+		return c, fmt.Errorf(
+			"unknown type %q: want %q, %q, %q, %q, %q or %q",
+			c.Type, CheckStatus, CheckHeader, CheckBodyRegex,
+			CheckJSONEquals, CheckJSONExists, CheckMaxBodySize,
+		)
+	}
+	return c, err
+}
+
+// check returns a non-nil error if the response resp
+// with status code code fails the compiled check c.
+func (c Check) check(code int, resp response) error {
+	switch c.Type {
+	case CheckStatus:
+		for _, r := range c.statusRanges {
+			if code >= r[0] && code <= r[1] {
+				return nil
+			}
+		}
+		return fmt.Errorf("status %d: want %s", code, c.Expression)
+	case CheckHeader:
+		values := resp.header.Values(c.Expression)
+		if len(values) == 0 {
+			return fmt.Errorf("header %s not found", c.Expression)
+		}
+		if !c.rgx.MatchString(values[0]) {
+			return fmt.Errorf("header %s: no match", c.Expression)
+		}
+		return nil
+	case CheckBodyRegex:
+		if !c.rgx.Match(resp.body) {
+			return errors.New("no match")
+		}
+		return nil
+	case CheckJSONEquals:
+		v, err := extractJSON(resp.body, c.Expression)
+		if err != nil {
+			return err
+		}
+		if v != c.Value {
+			return fmt.Errorf("%s: got %q, want %q", c.Expression, v, c.Value)
+		}
+		return nil
+	case CheckJSONExists:
+		_, err := extractJSON(resp.body, c.Expression)
+		return err
+	case CheckMaxBodySize:
+		if len(resp.body) > c.maxSize {
+			return fmt.Errorf("body size %d: want <= %d", len(resp.body), c.maxSize)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown check type: %q", c.Type)
+}
+
+// checkResponse returns the reason of the first of r.checks
+// failed by the response resp with status code code, along with
+// an error describing the failure prefixed with the reason,
+// or an empty string and nil if resp passes all of them.
+func (r *Recorder) checkResponse(code int, resp response) (string, error) {
+	for _, c := range r.checks {
+		if err := c.check(code, resp); err != nil {
+			return c.reason(), fmt.Errorf("%s: %w", c.reason(), err)
+		}
+	}
+	return "", nil
+}
+
+// parseStatusRanges parses a comma-separated list of status codes,
+// classes or ranges, e.g. "200,3xx,400-404", into inclusive ranges.
+func parseStatusRanges(expr string) ([][2]int, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, errors.New("empty status list")
+	}
+
+	parts := strings.Split(expr, ",")
+	ranges := make([][2]int, len(parts))
+	for i, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case len(part) == 3 && strings.HasSuffix(part, "xx"):
+			class, err := strconv.Atoi(part[:1])
+			if err != nil || class < 1 || class > 5 {
+				return nil, fmt.Errorf("invalid status class %q: want 1xx to 5xx", part)
+			}
+			ranges[i] = [2]int{class * 100, class*100 + 99}
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			from, errFrom := parseStatusCode(bounds[0])
+			to, errTo := parseStatusCode(bounds[1])
+			if errFrom != nil || errTo != nil || from > to {
+				return nil, fmt.Errorf("invalid status range %q", part)
+			}
+			ranges[i] = [2]int{from, to}
+		default:
+			code, err := parseStatusCode(part)
+			if err != nil {
+				return nil, err
+			}
+			ranges[i] = [2]int{code, code}
+		}
+	}
+	return ranges, nil
+}
+
+func parseStatusCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", s)
+	}
+	return code, nil
+}
@@ -0,0 +1,133 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheck_Validate(t *testing.T) {
+	for _, c := range []Check{
+		{Type: CheckStatus, Expression: "200, 3xx,400-404"},
+		{Type: CheckHeader, Expression: "Content-Type", Value: "^application/json"},
+		{Type: CheckBodyRegex, Expression: `"ok"`},
+		{Type: CheckJSONEquals, Expression: "/status", Value: "ok"},
+		{Type: CheckJSONExists, Expression: ""},
+		{Type: CheckMaxBodySize, Expression: "1024"},
+	} {
+		if err := c.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", c, err)
+		}
+	}
+
+	for _, c := range []Check{
+		{Type: "size"},
+		{Type: CheckStatus, Expression: ""},
+		{Type: CheckStatus, Expression: "6xx"},
+		{Type: CheckStatus, Expression: "299-200"},
+		{Type: CheckStatus, Expression: "20"},
+		{Type: CheckHeader, Expression: ""},
+		{Type: CheckHeader, Expression: "Content-Type", Value: "("},
+		{Type: CheckBodyRegex, Expression: "("},
+		{Type: CheckJSONEquals, Expression: "status"},
+		{Type: CheckMaxBodySize, Expression: "-1"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("exp error for %+v, got nil", c)
+		}
+	}
+}
+
+func TestCheck_check(t *testing.T) {
+	resp := response{
+		header: http.Header{"Content-Type": []string{"application/json"}},
+		body:   []byte(`{"status":"ok","count":2,"items":[{"id":"a"}]}`),
+	}
+
+	testcases := []struct {
+		check  Check
+		expErr bool
+	}{
+		{check: Check{Type: CheckStatus, Expression: "200"}},
+		{check: Check{Type: CheckStatus, Expression: "2xx"}},
+		{check: Check{Type: CheckStatus, Expression: "201,100-200"}},
+		{check: Check{Type: CheckStatus, Expression: "3xx,201-299"}, expErr: true},
+		{check: Check{Type: CheckHeader, Expression: "content-type"}},
+		{check: Check{Type: CheckHeader, Expression: "Content-Type", Value: "json$"}},
+		{check: Check{Type: CheckHeader, Expression: "Content-Type", Value: "^text/"}, expErr: true},
+		{check: Check{Type: CheckHeader, Expression: "X-Request-Id"}, expErr: true},
+		{check: Check{Type: CheckBodyRegex, Expression: `"count":\d`}},
+		{check: Check{Type: CheckBodyRegex, Expression: "error"}, expErr: true},
+		{check: Check{Type: CheckJSONEquals, Expression: "/status", Value: "ok"}},
+		{check: Check{Type: CheckJSONEquals, Expression: "/count", Value: "2"}},
+		{check: Check{Type: CheckJSONEquals, Expression: "/status", Value: "ko"}, expErr: true},
+		{check: Check{Type: CheckJSONExists, Expression: "/items/0/id"}},
+		{check: Check{Type: CheckJSONExists, Expression: "/items/1"}, expErr: true},
+		{check: Check{Type: CheckMaxBodySize, Expression: "100"}},
+		{check: Check{Type: CheckMaxBodySize, Expression: "10"}, expErr: true},
+	}
+
+	for _, tc := range testcases {
+		c, err := tc.check.compile()
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", tc.check, err)
+		}
+		err = c.check(200, resp)
+		if gotErr := err != nil; gotErr != tc.expErr {
+			t.Errorf("%+v: exp error %v, got %v", tc.check, tc.expErr, err)
+		}
+	}
+}
+
+func TestRecorder_Record_checks(t *testing.T) {
+	r := New(Config{
+		Requests:       4,
+		Concurrency:    1,
+		RequestTimeout: 1 * time.Second,
+		GlobalTimeout:  3 * time.Second,
+		Checks: []Check{
+			{Type: CheckStatus, Expression: "2xx"},
+			{Name: "status ok", Type: CheckJSONEquals, Expression: "/status", Value: "ok"},
+		},
+	})
+
+	var i int
+	r = withHandlerTransport(r, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		defer func() { i++ }()
+		// The first request is the ping.
+		switch i {
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"status":"error"}`)) //nolint:errcheck
+		case 3:
+			w.Write([]byte(`{"status":"degraded"}`)) //nolint:errcheck
+		default:
+			w.Write([]byte(`{"status":"ok"}`)) //nolint:errcheck
+		}
+	}))
+
+	records, err := r.Record(context.Background(), mustNewRequest("GET", validURI))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expReasons := []string{"", "status", "status ok", ""}
+	expDetails := []string{
+		"",
+		"status: status 500: want 2xx",
+		`status ok: /status: got "degraded", want "ok"`,
+		"",
+	}
+	for i, rec := range records {
+		if rec.Error != "" {
+			t.Fatalf("records[%d]: unexpected error: %s", i, rec.Error)
+		}
+		if rec.InvalidReason != expReasons[i] {
+			t.Errorf("records[%d]: exp invalid reason %q, got %q", i, expReasons[i], rec.InvalidReason)
+		}
+		if rec.InvalidDetail != expDetails[i] {
+			t.Errorf("records[%d]: exp invalid detail %q, got %q", i, expDetails[i], rec.InvalidDetail)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package recorder
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// DialConfig configures how the connections of the requests are opened.
+// A zero DialConfig resolves the hosts via the system DNS resolver
+// on each new connection.
+type DialConfig struct {
+	// Resolve maps hosts to the addresses connections are opened to
+	// instead, like curl --resolve. Keys are "host" or "host:port",
+	// the latter taking precedence, and values are "address" or
+	// "address:port", e.g. {"api.example.com:443": "10.0.0.12"}.
+	// The requests keep their host, e.g. in the Host header and SNI.
+	// Through a proxy, it applies to the targets of the CONNECT and
+	// SOCKS5 tunnels, but not to the http requests forwarded by an
+	// http proxy, which connects to their host itself.
+	Resolve map[string]string
+	// DNSCache caches the DNS resolutions for the duration of the run,
+	// so that only the first connection to a host reports DNS events.
+	DNSCache bool
+	// UnixSocket is the path of a Unix domain socket all connections
+	// are opened to, e.g. "/var/run/app.sock" or "unix:///var/run/app.sock".
+	// The requests keep their URL, e.g. "http://localhost/health".
+	UnixSocket string
+}
+
+// Validate returns a non-nil error if c is not a valid configuration.
+func (c DialConfig) Validate() error {
+	_, err := c.parse()
+	return err
+}
+
+// parse returns the dialer described by c, or nil if c is the zero value.
+func (c DialConfig) parse() (*dialer, error) {
+	if len(c.Resolve) == 0 && !c.DNSCache && c.UnixSocket == "" {
+		return nil, nil
+	}
+
+	d := &dialer{resolve: make(map[string]string, len(c.Resolve))}
+
+	for from, to := range c.Resolve {
+		if from == "" {
+			return nil, errors.New("resolve: want non-empty host")
+		}
+		if to == "" {
+			return nil, fmt.Errorf("resolve[%q]: want non-empty address", from)
+		}
+		d.resolve[strings.ToLower(from)] = to
+	}
+
+	if c.DNSCache {
+		d.cache = &dnsCache{entries: map[string][]string{}}
+	}
+
+	if c.UnixSocket != "" {
+		path := strings.TrimPrefix(c.UnixSocket, "unix://")
+		if path == "" || strings.Contains(path, "://") {
+			return nil, fmt.Errorf(`unixSocket (%q): want a path or a "unix://" URL`, c.UnixSocket)
+		}
+		d.unixSocket = path
+	}
+
+	return d, nil
+}
+
+// dialer is a parsed DialConfig. Its DNS cache is shared by the
+// transports of a run.
+type dialer struct {
+	resolve    map[string]string
+	cache      *dnsCache
+	unixSocket string
+}
+
+// dialFunc is the signature of http.Transport.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// apply returns a dial function opening the connections as configured
+// by d, using dial to open them. It returns dial if d is nil.
+func (d *dialer) apply(dial dialFunc) dialFunc {
+	if d == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if d.unixSocket != "" {
+			return dial(ctx, "unix", d.unixSocket)
+		}
+
+		addr = d.resolveAddr(addr)
+		if d.cache == nil {
+			return dial(ctx, network, addr)
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		ips, err := d.cache.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// applyTLS returns a dial function opening the connections as configured
+// by d, or directly if d is nil, and performing a TLS handshake, for
+// transports dialing TLS connections themselves such as http2.Transport.
+// As they do not call the TLS hooks of the client trace, the events
+// TLSHandshakeStart and TLSHandshakeDone are recorded by the dial function.
+func (d *dialer) applyTLS() func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+	dial := d.apply((&net.Dialer{}).DialContext)
+	return func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tracer := tracerFrom(ctx)
+		if tracer != nil {
+			tracer.addEvent("TLSHandshakeStart")
+		}
+		tlsConn := tls.Client(conn, cfg)
+		err = tlsConn.HandshakeContext(ctx)
+		if tracer != nil {
+			tracer.addEvent("TLSHandshakeDone")
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if p := tlsConn.ConnectionState().NegotiatedProtocol; p != http2.NextProtoTLS {
+			conn.Close()
+			return nil, fmt.Errorf("unexpected ALPN protocol %q: want %q", p, http2.NextProtoTLS)
+		}
+		return tlsConn, nil
+	}
+}
+
+// resolveAddr returns the address addr is mapped to by d.resolve,
+// or addr if it is not mapped or d is nil.
+func (d *dialer) resolveAddr(addr string) string {
+	if d == nil {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	to, ok := d.resolve[strings.ToLower(addr)]
+	if !ok {
+		if to, ok = d.resolve[strings.ToLower(host)]; !ok {
+			return addr
+		}
+	}
+	if _, _, err := net.SplitHostPort(to); err == nil {
+		return to
+	}
+	return net.JoinHostPort(strings.Trim(to, "[]"), port)
+}
+
+// dnsCache caches the IP addresses of hosts.
+type dnsCache struct {
+	mu      sync.Mutex
+	entries map[string][]string
+}
+
+// lookup returns the IP addresses of host, resolving them via
+// net.DefaultResolver on the first call for host.
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	ips, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok {
+		return ips, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips = make([]string, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP.String()
+	}
+
+	c.mu.Lock()
+	c.entries[host] = ips
+	c.mu.Unlock()
+	return ips, nil
+}
@@ -0,0 +1,189 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDialConfig_Validate(t *testing.T) {
+	for _, c := range []DialConfig{
+		{},
+		{Resolve: map[string]string{"example.com": "127.0.0.1", "example.com:443": "[::1]:8443"}},
+		{DNSCache: true},
+		{UnixSocket: "/var/run/app.sock"},
+		{UnixSocket: "unix:///var/run/app.sock"},
+	} {
+		if err := c.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", c, err)
+		}
+	}
+
+	for _, c := range []DialConfig{
+		{Resolve: map[string]string{"": "127.0.0.1"}},
+		{Resolve: map[string]string{"example.com": ""}},
+		{UnixSocket: "unix://"},
+		{UnixSocket: "http://localhost"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("exp error for %+v, got nil", c)
+		}
+	}
+}
+
+func TestDialer_resolveAddr(t *testing.T) {
+	d, err := DialConfig{Resolve: map[string]string{
+		"example.com":      "10.0.0.1",
+		"example.com:8443": "10.0.0.2:443",
+		"API.example.com":  "::1",
+	}}.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		addr string
+		exp  string
+	}{
+		{addr: "example.com:80", exp: "10.0.0.1:80"},
+		{addr: "example.com:8443", exp: "10.0.0.2:443"},
+		{addr: "api.example.com:443", exp: "[::1]:443"},
+		{addr: "other.com:443", exp: "other.com:443"},
+	}
+
+	for _, tc := range testcases {
+		if got := d.resolveAddr(tc.addr); got != tc.exp {
+			t.Errorf("%s: exp %s, got %s", tc.addr, tc.exp, got)
+		}
+	}
+}
+
+func TestRecorder_Record_dial(t *testing.T) {
+	newRecorder := func(cfg DialConfig) *Recorder {
+		return New(Config{
+			Requests:       3,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Dial:           cfg,
+			VirtualUsers:   VirtualUsers{Connection: ConnectionPerRequest},
+		})
+	}
+
+	t.Run("resolve hosts", func(t *testing.T) {
+		srv := newConnServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Host != "benchttp.test" {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		})
+		r := newRecorder(DialConfig{Resolve: map[string]string{
+			"benchttp.test": srv.Listener.Addr().String(),
+		}})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", "http://benchttp.test/"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertNoRecordError(t, records)
+		for i, rec := range records {
+			if rec.Code != http.StatusOK {
+				t.Errorf("records[%d]: exp request host to be kept, got status %d", i, rec.Code)
+			}
+		}
+		if got := srv.connections(); got != 4 {
+			t.Errorf("exp 4 connections to the server, got %d", got)
+		}
+	})
+
+	t.Run("cache dns resolutions", func(t *testing.T) {
+		srv := newConnServer(t, func(http.ResponseWriter, *http.Request) {})
+		_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+		uri := "http://localhost:" + port
+
+		for _, tc := range []struct {
+			cache     bool
+			expDNSHit bool
+		}{
+			{cache: false, expDNSHit: true},
+			{cache: true, expDNSHit: false},
+		} {
+			r := newRecorder(DialConfig{DNSCache: tc.cache})
+
+			records, err := r.Record(context.Background(), mustNewRequest("GET", uri))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertNoRecordError(t, records)
+			// The ping resolves the host first.
+			for i, rec := range records {
+				if got := hasEvent(rec, "DNSDone"); got != tc.expDNSHit {
+					t.Errorf("cache %v: records[%d]: exp DNSDone event %v, got %v", tc.cache, i, tc.expDNSHit, got)
+				}
+			}
+		}
+	})
+
+	t.Run("connect to unix socket", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "benchttp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "app.sock")
+
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		srv.Listener = ln
+		srv.Start()
+		defer srv.Close()
+
+		r := newRecorder(DialConfig{UnixSocket: "unix://" + path})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", "http://localhost/health"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertNoRecordError(t, records)
+		for i, rec := range records {
+			if rec.Code != http.StatusOK {
+				t.Errorf("records[%d]: exp status 200, got %d", i, rec.Code)
+			}
+		}
+	})
+
+	t.Run("return ErrConnection for invalid config", func(t *testing.T) {
+		r := newRecorder(DialConfig{UnixSocket: "unix://"})
+
+		_, err := r.Record(context.Background(), mustNewRequest("GET", "http://localhost"))
+		if !errors.Is(err, ErrConnection) {
+			t.Errorf("exp ErrConnection, got %v", err)
+		}
+	})
+}
+
+// helpers
+
+func assertNoRecordError(t *testing.T, records []Record) {
+	t.Helper()
+	for i, rec := range records {
+		if rec.Error != "" {
+			t.Fatalf("records[%d]: unexpected error: %s", i, rec.Error)
+		}
+	}
+}
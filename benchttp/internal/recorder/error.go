@@ -11,6 +11,15 @@ var (
 	ErrConnection = errors.New("connection error")
 	// ErrCanceled is returned when the Recorder.Run context is canceled.
 	ErrCanceled = errors.New("canceled")
+	// ErrAuthentication is returned when the Recorder fails to obtain
+	// the credentials of the requests before the run.
+	ErrAuthentication = errors.New("authentication error")
+	// ErrRender is returned when the templates of the requests
+	// cannot be rendered before the run.
+	ErrRender = errors.New("render error")
+	// ErrAborted is the Progress.Error of a run stopped early
+	// because an abort condition was met.
+	ErrAborted = errors.New("aborted")
 )
 
 // recordErr wraps and returns err as a string, marking it as an error
@@ -18,3 +27,27 @@ var (
 func recordErr(err error) string {
 	return fmt.Sprintf("recording error: %s", err)
 }
+
+// renderErr wraps and returns err as a string, marking it as an error
+// that happened when rendering the templates of the request.
+func renderErr(err error) string {
+	return fmt.Sprintf("render error: %s", err)
+}
+
+// authErr wraps and returns err as a string, marking it as an error
+// that happened when authenticating the request.
+func authErr(err error) string {
+	return fmt.Sprintf("authentication error: %s", err)
+}
+
+// signErr wraps and returns err as a string, marking it as an error
+// that happened when signing the request.
+func signErr(err error) string {
+	return fmt.Sprintf("signing error: %s", err)
+}
+
+// extractErr wraps and returns err as a string, marking it as an error
+// that happened when extracting a value with e.
+func extractErr(e Extractor, err error) string {
+	return fmt.Sprintf("extraction error: %s %s %q: %s", e.Var, e.Type, e.Expression, err)
+}
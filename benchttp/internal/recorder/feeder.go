@@ -0,0 +1,92 @@
+package recorder
+
+import (
+	"fmt"
+)
+
+// FeederMode determines which row of a Feeder an iteration uses.
+type FeederMode string
+
+const (
+	// FeedSequential uses the rows in order, one per iteration,
+	// starting over when all rows have been used.
+	FeedSequential FeederMode = "sequential"
+	// FeedRandom uses a random row for each iteration.
+	FeedRandom FeederMode = "random"
+	// FeedPerWorker assigns a row to each concurrent worker, that uses it
+	// for all its iterations, e.g. a user account per virtual user.
+	FeedPerWorker FeederMode = "perWorker"
+)
+
+// Feeder provides rows of data to the templates of the requests:
+// the values of the row used by an iteration are available
+// by column name, e.g. "/users/{{.userID}}".
+type Feeder struct {
+	Rows []map[string]string
+	// Mode determines which row each iteration uses.
+	// Defaults to FeedSequential.
+	Mode FeederMode
+}
+
+// Validate returns a non-nil error if f cannot be used to feed
+// iterations. A zero Feeder is valid.
+func (f Feeder) Validate() error {
+	switch f.Mode {
+	case "", FeedSequential, FeedRandom, FeedPerWorker:
+	default:
+		return fmt.Errorf(
+			"unknown mode %q: want %q, %q or %q",
+			f.Mode, FeedSequential, FeedRandom, FeedPerWorker,
+		)
+	}
+
+	for i, row := range f.Rows {
+		for column := range row {
+			if column == dataIteration || column == dataWorker {
+				return fmt.Errorf("rows[%d]: column %q: reserved name", i, column)
+			}
+		}
+	}
+	return nil
+}
+
+// feeder picks the rows of a Feeder for the iterations of a run.
+// A nil *feeder picks no row.
+type feeder struct {
+	Feeder
+}
+
+// newFeeder returns a feeder picking the rows of f.
+// It returns nil if f has no rows.
+func newFeeder(f Feeder) *feeder {
+	if len(f.Rows) == 0 {
+		return nil
+	}
+	return &feeder{Feeder: f}
+}
+
+// row returns the row used by it. For FeedRandom, the row is picked
+// from the seed of it. It is safe for concurrent use.
+func (f *feeder) row(it iteration) map[string]string {
+	if f == nil {
+		return nil
+	}
+	switch f.Mode {
+	case FeedRandom:
+		// The seed is mixed again so that the picked row does not
+		// correlate with the random values rendered from it.
+		return f.Rows[splitmix64(uint64(it.seed))%uint64(len(f.Rows))]
+	case FeedPerWorker:
+		return f.Rows[it.worker%len(f.Rows)]
+	default:
+		return f.Rows[it.index%len(f.Rows)]
+	}
+}
+
+// first returns the first row, or nil if f is nil.
+func (f *feeder) first() map[string]string {
+	if f == nil {
+		return nil
+	}
+	return f.Rows[0]
+}
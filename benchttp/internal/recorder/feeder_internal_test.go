@@ -0,0 +1,129 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFeeder_Validate(t *testing.T) {
+	t.Run("return nil for valid feeders", func(t *testing.T) {
+		for _, f := range []Feeder{
+			{},
+			{Rows: []map[string]string{{"id": "1"}}},
+			{Rows: []map[string]string{{"id": "1"}}, Mode: FeedRandom},
+			{Rows: []map[string]string{{"id": "1"}}, Mode: FeedPerWorker},
+		} {
+			if err := f.Validate(); err != nil {
+				t.Errorf("unexpected error for %+v: %v", f, err)
+			}
+		}
+	})
+
+	t.Run("return an error for invalid feeders", func(t *testing.T) {
+		for _, f := range []Feeder{
+			{Rows: []map[string]string{{"id": "1"}}, Mode: "circular"},
+			{Rows: []map[string]string{{"id": "1"}, {dataIteration: "1"}}},
+		} {
+			if err := f.Validate(); err == nil {
+				t.Errorf("exp error for %+v, got nil", f)
+			}
+		}
+	})
+}
+
+func TestFeeder_row(t *testing.T) {
+	rows := []map[string]string{{"id": "0"}, {"id": "1"}, {"id": "2"}}
+
+	pickIDs := func(f *feeder, its []iteration) []string {
+		ids := make([]string, len(its))
+		for i, it := range its {
+			ids[i] = f.row(it)["id"]
+		}
+		return ids
+	}
+
+	t.Run("sequential mode uses rows in order and starts over", func(t *testing.T) {
+		f := newFeeder(Feeder{Rows: rows})
+		its := []iteration{{index: 0}, {index: 1}, {index: 2}, {index: 3}}
+
+		exp := []string{"0", "1", "2", "0"}
+		if got := pickIDs(f, its); !reflect.DeepEqual(got, exp) {
+			t.Errorf("exp %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("per worker mode uses a row per worker", func(t *testing.T) {
+		f := newFeeder(Feeder{Rows: rows, Mode: FeedPerWorker})
+		its := []iteration{{index: 0, worker: 1}, {index: 1, worker: 1}, {index: 2, worker: 4}}
+
+		exp := []string{"1", "1", "1"}
+		if got := pickIDs(f, its); !reflect.DeepEqual(got, exp) {
+			t.Errorf("exp %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("random mode picks rows from the iteration seeds", func(t *testing.T) {
+		f := newFeeder(Feeder{Rows: rows, Mode: FeedRandom})
+		its := make([]iteration, 20)
+		for i := range its {
+			its[i] = iteration{index: i, seed: iterationSeed(42, i)}
+		}
+		reversed := make([]iteration, len(its))
+		for i, it := range its {
+			reversed[len(its)-1-i] = it
+		}
+
+		a, b := pickIDs(f, its), pickIDs(f, reversed)
+		for i := range a {
+			if a[i] != b[len(b)-1-i] {
+				t.Fatalf("exp same rows for same seeds in any order, got %v and reversed %v", a, b)
+			}
+		}
+		if reflect.DeepEqual(a, pickIDs(f, make([]iteration, 20))) {
+			t.Errorf("exp rows depending on the seeds, got %v", a)
+		}
+	})
+
+	t.Run("nil feeder returns no row", func(t *testing.T) {
+		if f := newFeeder(Feeder{}); f.row(iteration{}) != nil || f.first() != nil {
+			t.Error("exp nil rows from feeder without rows")
+		}
+	})
+}
+
+func TestRecorder_Record_feeder(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		gotPaths []string
+	)
+
+	r := withHandlerTransport(New(Config{
+		Requests:       3,
+		Concurrency:    1,
+		RequestTimeout: 1 * time.Second,
+		GlobalTimeout:  3 * time.Second,
+		Feeder: Feeder{Rows: []map[string]string{
+			{"user": "alice"},
+			{"user": "bob"},
+		}},
+	}), http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		gotPaths = append(gotPaths, req.URL.Path)
+	}))
+
+	req := mustNewRequest("GET", validURI+"/users/{{.user}}")
+	if _, err := r.Record(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first path is sent by the ping, with the first row.
+	exp := []string{"/users/alice", "/users/alice", "/users/bob", "/users/alice"}
+	if !reflect.DeepEqual(gotPaths, exp) {
+		t.Errorf("unexpected requests:\nexp %v\ngot %v", exp, gotPaths)
+	}
+}
@@ -1,8 +1,12 @@
 package recorder
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,15 +18,51 @@ func newClient(transport http.RoundTripper, timeout time.Duration) *http.Client
 	}
 }
 
-// cloneRequest fully clones a *http.Request by also cloning the body
-// via Request.GetBody.
-func cloneRequest(req *http.Request) *http.Request {
-	reqClone := req.Clone(req.Context())
-	if req.Body != nil {
-		// err is always nil (https://golang.org/src/net/http/request.go#L889)
-		reqClone.Body, _ = req.GetBody()
+// cloneRequest fully clones a *http.Request with the given context
+// by also cloning the body via Request.GetBody. A http.NoBody body
+// is kept as is. It returns a non-nil error if the body cannot be
+// cloned, e.g. if a BodyProvider fails to open it.
+func cloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	reqClone := req.Clone(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body cannot be replayed: GetBody is nil")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+		reqClone.Body = body
 	}
-	return reqClone
+	return reqClone, nil
+}
+
+// countingBody is a request body counting the bytes read from it
+// by the transport, i.e. the bytes of the body sent.
+type countingBody struct {
+	n int64 // first for 64-bit alignment of atomic operations
+	io.ReadCloser
+}
+
+// countBody replaces the body of req with a countingBody and returns it.
+// The returned countingBody counts zero bytes if req has no body.
+func countBody(req *http.Request) *countingBody {
+	b := &countingBody{ReadCloser: req.Body}
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = b
+	}
+	return b
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.n, int64(n))
+	return n, err
+}
+
+// count returns the count of bytes read from b.
+func (b *countingBody) count() int {
+	return int(atomic.LoadInt64(&b.n))
 }
 
 // readClose reads resp.Body and closes it.
@@ -30,3 +70,16 @@ func readClose(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 	return io.ReadAll(resp.Body)
 }
+
+// sleep pauses the current goroutine for at least d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
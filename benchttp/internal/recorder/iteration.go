@@ -0,0 +1,92 @@
+package recorder
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/benchttp/engine/benchttp/internal/render"
+)
+
+// Template data keys set for each iteration. They cannot be used
+// as variable names.
+const (
+	dataIteration = "Iteration"
+	dataWorker    = "Worker"
+)
+
+// iteration represents a single iteration of a run.
+type iteration struct {
+	// index is the index of the iteration in the run, starting at 0.
+	index int
+	// worker is the index of the worker running the iteration, in
+	// [0, n) with n the maximum number of concurrent iterations.
+	worker int
+	// warmup is true if the iteration is part of the warm-up.
+	warmup bool
+	// seed is the seed of the random values of the iteration,
+	// derived from Config.Seed and index by iterationSeed.
+	seed int64
+	// row is the row of the Feeder used by the iteration, if any.
+	row map[string]string
+	// renderer renders the templates of the iteration, generating
+	// random values from seed.
+	renderer *render.Renderer
+	// user is the virtual user of the worker running the iteration.
+	user *virtualUser
+}
+
+// data returns the template data of the iteration:
+// {{.Iteration}}, {{.Worker}} and the columns of its row.
+func (it iteration) data() map[string]string {
+	data := make(map[string]string, len(it.row)+2)
+	for column, v := range it.row {
+		data[column] = v
+	}
+	data[dataIteration] = strconv.Itoa(it.index)
+	data[dataWorker] = strconv.Itoa(it.worker)
+	return data
+}
+
+// iterationSeed returns the seed of the random values of the iteration
+// of index i of a run seeded with seed. Deriving it from both rather than
+// drawing from a shared source makes the values independent from the
+// order concurrent iterations run in.
+func iterationSeed(seed int64, i int) int64 {
+	return int64(splitmix64(uint64(seed) + uint64(i)*0x9e3779b97f4a7c15))
+}
+
+// splitmix64 returns the SplitMix64 mix of x.
+func splitmix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// workerPool assigns worker indexes to concurrent iterations, so that
+// each running iteration has a distinct index, the lowest available.
+// Its zero value is ready to use.
+type workerPool struct {
+	mu   sync.Mutex
+	busy []bool
+}
+
+// acquire returns the lowest available worker index and marks it busy.
+func (p *workerPool) acquire() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, busy := range p.busy {
+		if !busy {
+			p.busy[i] = true
+			return i
+		}
+	}
+	p.busy = append(p.busy, true)
+	return len(p.busy) - 1
+}
+
+// release marks the worker index i available.
+func (p *workerPool) release(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.busy[i] = false
+}
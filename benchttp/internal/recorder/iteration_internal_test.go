@@ -0,0 +1,58 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	var p workerPool
+
+	for exp := 0; exp < 3; exp++ {
+		if got := p.acquire(); got != exp {
+			t.Errorf("exp worker %d, got %d", exp, got)
+		}
+	}
+
+	p.release(1)
+	if got := p.acquire(); got != 1 {
+		t.Errorf("exp released worker 1 to be reused, got %d", got)
+	}
+}
+
+func TestRecorder_Record_templates(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		gotPaths  []string
+		isPingReq = true
+	)
+
+	r := withHandlerTransport(New(Config{
+		Requests:       3,
+		Concurrency:    1,
+		RequestTimeout: 1 * time.Second,
+		GlobalTimeout:  3 * time.Second,
+	}), http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if isPingReq {
+			isPingReq = false
+			return
+		}
+		gotPaths = append(gotPaths, req.URL.RequestURI())
+	}))
+
+	req := mustNewRequest("GET", validURI+"/items/{{.Iteration}}?worker={{.Worker}}")
+	if _, err := r.Record(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := "/items/0?worker=0 /items/1?worker=0 /items/2?worker=0"
+	if got := strings.Join(gotPaths, " "); got != exp {
+		t.Errorf("unexpected requests:\nexp %s\ngot %s", exp, got)
+	}
+}
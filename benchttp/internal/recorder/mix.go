@@ -0,0 +1,53 @@
+package recorder
+
+import (
+	"net/http"
+	"sync"
+)
+
+// WeightedRequest is a named request of a mix. It is sent in proportion
+// to its Weight relatively to the sum of the weights of the mix.
+type WeightedRequest struct {
+	Name    string
+	Weight  int
+	Request *http.Request
+}
+
+// requestPicker picks the requests of a mix according to their weights.
+// It uses a smooth weighted round-robin, so that the proportions are
+// exact over each cycle of sum(weights) picks and the requests
+// of a same name are evenly spread over the cycle.
+type requestPicker struct {
+	mu      sync.Mutex
+	mix     []WeightedRequest
+	current []int
+	total   int
+}
+
+func newRequestPicker(mix []WeightedRequest) *requestPicker {
+	total := 0
+	for _, wr := range mix {
+		total += wr.Weight
+	}
+	return &requestPicker{
+		mix:     mix,
+		current: make([]int, len(mix)),
+		total:   total,
+	}
+}
+
+// next returns the next request to send. It is safe for concurrent use.
+func (p *requestPicker) next() WeightedRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	best := 0
+	for i, wr := range p.mix {
+		p.current[i] += wr.Weight
+		if p.current[i] > p.current[best] {
+			best = i
+		}
+	}
+	p.current[best] -= p.total
+	return p.mix[best]
+}
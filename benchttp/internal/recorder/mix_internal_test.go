@@ -0,0 +1,50 @@
+package recorder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestPicker(t *testing.T) {
+	picker := newRequestPicker([]WeightedRequest{
+		{Name: "a", Weight: 1},
+		{Name: "b", Weight: 3},
+	})
+
+	got := ""
+	for i := 0; i < 8; i++ {
+		got += picker.next().Name
+	}
+
+	// smooth weighted round-robin spreads "b" evenly over each cycle
+	if exp := "babbbabb"; got != exp {
+		t.Errorf("unexpected picks: exp %q, got %q", exp, got)
+	}
+}
+
+func TestRecorder_RecordMix(t *testing.T) {
+	r := withNoopTransport(New(Config{
+		Requests:       8,
+		Concurrency:    1,
+		RequestTimeout: 1 * time.Second,
+		GlobalTimeout:  3 * time.Second,
+	}))
+
+	recs, err := r.RecordMix(context.Background(), []WeightedRequest{
+		{Name: "a", Weight: 1, Request: validRequest()},
+		{Name: "b", Weight: 3, Request: validRequest()},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	countByName := map[string]int{}
+	for _, rec := range recs {
+		countByName[rec.Name]++
+	}
+
+	if countByName["a"] != 2 || countByName["b"] != 6 {
+		t.Errorf("unexpected records count by name: exp a: 2, b: 6, got %v", countByName)
+	}
+}
@@ -0,0 +1,62 @@
+package recorder
+
+import "time"
+
+// Phases are the durations of the successive phases of a request,
+// derived from its events. A zero duration means the phase did not
+// occur, e.g. DNS, Connect and TLS for a reused connection.
+type Phases struct {
+	// DNS is the time spent resolving the host, from DNSStart
+	// to DNSDone.
+	DNS time.Duration
+	// Connect is the time spent opening the TCP connection, from
+	// ConnectStart to ConnectDone.
+	Connect time.Duration
+	// TLS is the time spent in the TLS handshake, from
+	// TLSHandshakeStart to TLSHandshakeDone.
+	TLS time.Duration
+	// Write is the time spent writing the request, from GotConn
+	// to WroteRequest.
+	Write time.Duration
+	// Wait is the time spent waiting for the server, from WroteRequest
+	// to GotFirstResponseByte (time to first byte).
+	Wait time.Duration
+	// Transfer is the time spent reading the response, from
+	// GotFirstResponseByte to BodyRead.
+	Transfer time.Duration
+}
+
+// newPhases returns the Phases derived from events. If an event occurs
+// several times, e.g. ConnectStart for concurrent dials, the phase spans
+// from its first start to its last end.
+func newPhases(events []Event) Phases {
+	first := map[string]time.Duration{}
+	last := map[string]time.Duration{}
+	for _, e := range events {
+		if _, ok := first[e.Name]; !ok {
+			first[e.Name] = e.Time
+		}
+		last[e.Name] = e.Time
+	}
+
+	between := func(start, end string) time.Duration {
+		from, ok := first[start]
+		if !ok {
+			return 0
+		}
+		to, ok := last[end]
+		if !ok || to < from {
+			return 0
+		}
+		return to - from
+	}
+
+	return Phases{
+		DNS:      between("DNSStart", "DNSDone"),
+		Connect:  between("ConnectStart", "ConnectDone"),
+		TLS:      between("TLSHandshakeStart", "TLSHandshakeDone"),
+		Write:    between("GotConn", "WroteRequest"),
+		Wait:     between("WroteRequest", "GotFirstResponseByte"),
+		Transfer: between("GotFirstResponseByte", "BodyRead"),
+	}
+}
@@ -0,0 +1,34 @@
+package recorder
+
+import "testing"
+
+func TestNewPhases(t *testing.T) {
+	events := []Event{
+		{Name: "GetConn", Time: 1},
+		{Name: "DNSStart", Time: 2},
+		{Name: "DNSDone", Time: 5},
+		{Name: "ConnectStart", Time: 6},
+		{Name: "ConnectStart", Time: 7},
+		{Name: "ConnectDone", Time: 10},
+		{Name: "ConnectDone", Time: 12},
+		{Name: "TLSHandshakeStart", Time: 13},
+		{Name: "TLSHandshakeDone", Time: 20},
+		{Name: "GotConn", Time: 21},
+		{Name: "WroteHeaders", Time: 22},
+		{Name: "WroteRequest", Time: 24},
+		{Name: "GotFirstResponseByte", Time: 40},
+		{Name: "BodyRead", Time: 45},
+	}
+
+	exp := Phases{DNS: 3, Connect: 6, TLS: 7, Write: 3, Wait: 16, Transfer: 5}
+	if got := newPhases(events); got != exp {
+		t.Errorf("exp %+v, got %+v", exp, got)
+	}
+
+	t.Run("zero phases for reused connection", func(t *testing.T) {
+		exp := Phases{Write: 3, Wait: 16, Transfer: 5}
+		if got := newPhases(events[9:]); got != exp {
+			t.Errorf("exp %+v, got %+v", exp, got)
+		}
+	})
+}
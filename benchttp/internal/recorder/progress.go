@@ -12,6 +12,7 @@ const (
 	StatusRunning  Status = "RUNNING"
 	StatusCanceled Status = "CANCELED"
 	StatusTimeout  Status = "TIMEOUT"
+	StatusAborted  Status = "ABORTED"
 	StatusDone     Status = "DONE"
 )
 
@@ -27,16 +28,29 @@ type Progress struct {
 func (r *Recorder) Progress() Progress {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	doneCount := len(r.records)
+	if r.isScenario {
+		doneCount = len(r.iterations)
+	}
 	return Progress{
 		Done:      r.done,
 		Error:     r.runErr,
-		DoneCount: len(r.records),
+		DoneCount: doneCount,
 		MaxCount:  r.config.Requests,
-		Timeout:   r.config.GlobalTimeout,
+		Timeout:   r.maxDuration(),
 		Elapsed:   time.Since(r.start),
 	}
 }
 
+// maxDuration returns the maximum duration of the run:
+// its duration if shorter than the global timeout, else the global timeout.
+func (r *Recorder) maxDuration() time.Duration {
+	if d := r.runDuration(); d > 0 && d < r.config.GlobalTimeout {
+		return d
+	}
+	return r.config.GlobalTimeout
+}
+
 func (s Progress) JSON() ([]byte, error) {
 	return json.Marshal(s)
 }
@@ -54,6 +68,8 @@ func (s Progress) Status() Status {
 		return StatusCanceled
 	case context.DeadlineExceeded:
 		return StatusTimeout
+	case ErrAborted:
+		return StatusAborted
 	}
 	return "" // should not occur
 }
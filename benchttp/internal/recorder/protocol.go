@@ -0,0 +1,116 @@
+package recorder
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// Protocol is the HTTP protocol used to send requests.
+type Protocol string
+
+const (
+	// ProtocolAuto uses HTTP/2 if negotiated with the server over TLS,
+	// HTTP/1.1 otherwise.
+	ProtocolAuto Protocol = "auto"
+	// ProtocolHTTP1 always uses HTTP/1.1.
+	ProtocolHTTP1 Protocol = "http1"
+	// ProtocolH2 always uses HTTP/2 over TLS. Requests to http URLs fail.
+	ProtocolH2 Protocol = "h2"
+	// ProtocolH2C always uses HTTP/2 over cleartext TCP with prior
+	// knowledge, i.e. without upgrading from HTTP/1.1. Requests
+	// to https URLs use HTTP/2 over TLS.
+	ProtocolH2C Protocol = "h2c"
+)
+
+// Validate returns a non-nil error if p is not a known Protocol.
+// The empty Protocol is valid and equivalent to ProtocolAuto.
+func (p Protocol) Validate() error {
+	switch p {
+	case "", ProtocolAuto, ProtocolHTTP1, ProtocolH2, ProtocolH2C:
+		return nil
+	default:
+		return fmt.Errorf(
+			"unknown protocol %q: want %q, %q, %q or %q",
+			p, ProtocolAuto, ProtocolHTTP1, ProtocolH2, ProtocolH2C,
+		)
+	}
+}
+
+// newProtocolTransport returns a new http.RoundTripper sending
+// requests with protocol p and a copy of tlsConfig, if not nil,
+// as the TLS configuration. The connections are opened by dialer
+// if not nil. The requests are sent through proxy if not nil,
+// which is only supported by protocols auto and http1.
+func newProtocolTransport(p Protocol, tlsConfig *tls.Config, dialer *dialer, proxy *proxy) http.RoundTripper {
+	switch p {
+	case ProtocolHTTP1:
+		t := newHTTPTransport(tlsConfig, dialer)
+		proxy.apply(t, dialer)
+		t.ForceAttemptHTTP2 = false
+		// A non-nil empty map disables HTTP/2.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return t
+	case ProtocolH2:
+		return newH2Transport(tlsConfig, dialer)
+	case ProtocolH2C:
+		dial := dialer.apply((&net.Dialer{}).DialContext)
+		return &h2cTransport{
+			h2c: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					return dial(ctx, network, addr)
+				},
+			},
+			h2: newH2Transport(tlsConfig, dialer),
+		}
+	default:
+		t := newHTTPTransport(tlsConfig, dialer)
+		proxy.apply(t, dialer)
+		return t
+	}
+}
+
+// h2cTransport is a http.RoundTripper sending requests to http URLs
+// over HTTP/2 cleartext and other requests over HTTP/2 over TLS.
+type h2cTransport struct {
+	h2c *http2.Transport
+	h2  *http2.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.h2.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *h2cTransport) CloseIdleConnections() {
+	t.h2c.CloseIdleConnections()
+	t.h2.CloseIdleConnections()
+}
+
+// newHTTPTransport returns a new http.Transport configured
+// as http.DefaultTransport, with a copy of tlsConfig, opening
+// its connections with dialer if not nil.
+func newHTTPTransport(tlsConfig *tls.Config, dialer *dialer) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = newClientTLSConfig(tlsConfig)
+	t.DialContext = dialer.apply(t.DialContext)
+	return t
+}
+
+// newH2Transport returns a new http2.Transport with a copy
+// of tlsConfig, opening its connections with dialer if not nil.
+func newH2Transport(tlsConfig *tls.Config, dialer *dialer) *http2.Transport {
+	return &http2.Transport{
+		TLSClientConfig: newClientTLSConfig(tlsConfig),
+		DialTLSContext:  dialer.applyTLS(),
+	}
+}
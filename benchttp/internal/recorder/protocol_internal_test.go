@@ -0,0 +1,111 @@
+package recorder
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestProtocol_Validate(t *testing.T) {
+	for _, p := range []Protocol{"", ProtocolAuto, ProtocolHTTP1, ProtocolH2, ProtocolH2C} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", p, err)
+		}
+	}
+
+	if err := Protocol("h3").Validate(); err == nil {
+		t.Error("exp error for unknown protocol, got nil")
+	}
+}
+
+func TestRecorder_Record_protocol(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	tlsServer := httptest.NewUnstartedServer(noop)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	h2cServer := httptest.NewServer(h2c.NewHandler(noop, &http2.Server{}))
+	defer h2cServer.Close()
+
+	testcases := []struct {
+		protocol Protocol
+		server   *httptest.Server
+		expProto string
+	}{
+		{protocol: "", server: tlsServer, expProto: "HTTP/2.0"},
+		{protocol: ProtocolAuto, server: h2cServer, expProto: "HTTP/1.1"},
+		{protocol: ProtocolHTTP1, server: tlsServer, expProto: "HTTP/1.1"},
+		{protocol: ProtocolH2, server: tlsServer, expProto: "HTTP/2.0"},
+		{protocol: ProtocolH2C, server: h2cServer, expProto: "HTTP/2.0"},
+		{protocol: ProtocolH2C, server: tlsServer, expProto: "HTTP/2.0"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(string(tc.protocol)+" "+tc.server.URL, func(t *testing.T) {
+			r := New(Config{
+				Requests:       2,
+				Concurrency:    1,
+				RequestTimeout: 1 * time.Second,
+				GlobalTimeout:  3 * time.Second,
+				Protocol:       tc.protocol,
+			})
+			r.newTransport = func() http.RoundTripper {
+				return newProtocolTransport(tc.protocol, trustedTLSConfig(tc.server), nil, nil)
+			}
+
+			records, err := r.Record(context.Background(), mustNewRequest("GET", tc.server.URL))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i, rec := range records {
+				if rec.Error != "" {
+					t.Fatalf("records[%d]: unexpected error: %s", i, rec.Error)
+				}
+				if rec.Proto != tc.expProto {
+					t.Errorf("records[%d]: exp proto %q, got %q", i, tc.expProto, rec.Proto)
+				}
+			}
+			// The first request opens the connection of the virtual user.
+			if tc.server == tlsServer {
+				if records[0].TLSVersion == "" {
+					t.Errorf("records[0]: exp TLS handshake, got none")
+				}
+				if records[0].Phases.TLS <= 0 {
+					t.Errorf("records[0]: exp TLS phase > 0, got %v", records[0].Phases.TLS)
+				}
+			}
+		})
+	}
+
+	t.Run("h2 fails for http URLs", func(t *testing.T) {
+		r := New(Config{
+			Requests:       1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Protocol:       ProtocolH2,
+		})
+
+		if _, err := r.Record(context.Background(), mustNewRequest("GET", h2cServer.URL)); err == nil {
+			t.Error("exp connection error, got nil")
+		}
+	})
+}
+
+// trustedTLSConfig returns a *tls.Config trusting the certificate
+// of the test server srv, or nil if srv does not use TLS.
+func trustedTLSConfig(srv *httptest.Server) *tls.Config {
+	if srv.TLS == nil {
+		return nil
+	}
+	return srv.Client().Transport.(*http.Transport).TLSClientConfig
+}
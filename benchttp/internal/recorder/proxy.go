@@ -0,0 +1,303 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// Proxy configures the proxy the requests are sent through.
+// A zero Proxy sends the requests directly.
+//
+// With an http or https proxy, requests to https URLs are tunneled
+// via HTTP CONNECT and requests to http URLs are forwarded to the proxy.
+// With a socks5 proxy, all requests are tunneled. When a tunnel is
+// established, the event ProxyConnect is recorded.
+type Proxy struct {
+	// URL is the URL of the proxy, e.g. "http://proxy.internal:3128"
+	// or "socks5://localhost:1080".
+	URL string
+	// Username and Password are the credentials sent to the proxy,
+	// if any. They take precedence over the user info of URL.
+	Username string
+	Password string
+	// NoProxy lists the hosts requests are sent to directly: host names,
+	// domain names matching their subdomains if they start with a dot
+	// (".example.com"), IP addresses, CIDR ranges, or "*" for all hosts.
+	NoProxy []string
+}
+
+// Validate returns a non-nil error if p is not a valid configuration.
+// A zero Proxy is valid.
+func (p Proxy) Validate() error {
+	_, err := p.parse()
+	return err
+}
+
+// parse returns the proxy described by p, or nil if p.URL is empty.
+func (p Proxy) parse() (*proxy, error) {
+	if p.URL == "" {
+		if p.Username != "" || len(p.NoProxy) > 0 {
+			return nil, errors.New("url: want non-empty")
+		}
+		return nil, nil
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("url: %s", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf(`url (%q): want scheme "http", "https" or "socks5"`, p.URL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("url (%q): want a host", p.URL)
+	}
+	if p.Username != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+
+	noProxy := make([]noProxyRule, len(p.NoProxy))
+	for i, pattern := range p.NoProxy {
+		rule, err := parseNoProxyRule(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("noProxy[%d]: %s", i, err)
+		}
+		noProxy[i] = rule
+	}
+
+	return &proxy{url: u, noProxy: noProxy}, nil
+}
+
+// proxy is a parsed Proxy.
+type proxy struct {
+	url     *url.URL
+	noProxy []noProxyRule
+}
+
+// apply configures t to send its requests through p, tunneling them
+// to the addresses the target hosts are mapped to by d if not nil.
+// It is a no-op if p is nil.
+func (p *proxy) apply(t *http.Transport, d *dialer) {
+	if p == nil {
+		return
+	}
+
+	if p.url.Scheme == "socks5" {
+		t.Proxy = nil
+		t.DialContext = p.dialSOCKS5(t.DialContext, d)
+		return
+	}
+
+	// Requests to https URLs are not proxied by t: they are sent over
+	// the tunnels opened by dialConnect, which times their CONNECT.
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		if req.URL.Scheme == "https" || p.bypass(req.URL.Host) {
+			return nil, nil
+		}
+		return p.url, nil
+	}
+	t.DialContext = p.dialConnect(t.DialContext, t.TLSClientConfig, d)
+}
+
+// dialConnect returns a dial function connecting to addresses through
+// a tunnel established via HTTP CONNECT with the http or https proxy p,
+// using dial to connect to the proxy or directly to the bypassed hosts.
+// The tunnels are opened to the addresses mapped by d, if not nil.
+// The connections to p itself, for forwarded requests, are not tunneled.
+// tlsConfig is used for the TLS handshake with an https proxy.
+func (p *proxy) dialConnect(dial dialFunc, tlsConfig *tls.Config, d *dialer) dialFunc {
+	proxyAddr := canonicalAddr(p.url)
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == proxyAddr || p.bypass(addr) {
+			return dial(ctx, network, addr)
+		}
+
+		conn, err := dial(ctx, network, proxyAddr)
+		if err != nil {
+			return nil, err
+		}
+		if p.url.Scheme == "https" {
+			cfg := &tls.Config{}
+			if tlsConfig != nil {
+				cfg = tlsConfig.Clone()
+			}
+			cfg.ServerName = p.url.Hostname()
+			tlsConn := tls.Client(conn, cfg)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			conn = tlsConn
+		}
+
+		if err := p.connect(ctx, conn, d.resolveAddr(addr)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if tracer := tracerFrom(ctx); tracer != nil {
+			tracer.addEvent("ProxyConnect")
+		}
+		return conn, nil
+	}
+}
+
+// connect sends a CONNECT request for addr to the proxy p over conn
+// and reads its response. It returns a non-nil error if the tunnel
+// is not established.
+func (p *proxy) connect(ctx context.Context, conn net.Conn, addr string) error {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := p.url.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	// Unblock the reads and writes on conn if ctx is done.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)          //nolint:errcheck
+		defer conn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+	// The proxy does not send anything after the response to CONNECT
+	// before the tunneled data is written.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxy: CONNECT %s: %s", addr, resp.Status)
+	}
+	return nil
+}
+
+// canonicalAddr returns the host:port of u, adding the default
+// port of its scheme if it has none.
+func canonicalAddr(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// dialSOCKS5 returns a dial function connecting to addresses through
+// the SOCKS5 proxy p, using dial to connect to the proxy or directly
+// to the bypassed hosts.
+func (p *proxy) dialSOCKS5(dial dialFunc, d *dialer) dialFunc {
+	var auth *xproxy.Auth
+	if user := p.url.User; user != nil {
+		password, _ := user.Password()
+		auth = &xproxy.Auth{User: user.Username(), Password: password}
+	}
+	// err is always nil for a socks5 dialer.
+	socks, _ := xproxy.SOCKS5("tcp", p.url.Host, auth, dialerFunc(dial))
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if p.bypass(addr) {
+			return dial(ctx, network, addr)
+		}
+		conn, err := socks.(xproxy.ContextDialer).DialContext(ctx, network, d.resolveAddr(addr))
+		if err != nil {
+			return nil, err
+		}
+		if tracer := tracerFrom(ctx); tracer != nil {
+			tracer.addEvent("ProxyConnect")
+		}
+		return conn, nil
+	}
+}
+
+// bypass returns true if requests to hostport must not be proxied.
+func (p *proxy) bypass(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	for _, rule := range p.noProxy {
+		if rule.match(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialerFunc implements xproxy.Dialer and xproxy.ContextDialer.
+type dialerFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (f dialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+func (f dialerFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// noProxyRule is a parsed entry of Proxy.NoProxy.
+type noProxyRule struct {
+	all    bool
+	host   string
+	domain string
+	ipNet  *net.IPNet
+}
+
+func parseNoProxyRule(pattern string) (noProxyRule, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	switch {
+	case pattern == "":
+		return noProxyRule{}, errors.New("want non-empty")
+	case pattern == "*":
+		return noProxyRule{all: true}, nil
+	case strings.HasPrefix(pattern, "."):
+		return noProxyRule{domain: pattern}, nil
+	case strings.Contains(pattern, "/"):
+		_, ipNet, err := net.ParseCIDR(pattern)
+		if err != nil {
+			return noProxyRule{}, err
+		}
+		return noProxyRule{ipNet: ipNet}, nil
+	default:
+		return noProxyRule{host: pattern}, nil
+	}
+}
+
+// match returns true if the lowercase host matches r.
+func (r noProxyRule) match(host string) bool {
+	switch {
+	case r.all:
+		return true
+	case r.domain != "":
+		return strings.HasSuffix(host, r.domain) || host == r.domain[1:]
+	case r.ipNet != nil:
+		ip := net.ParseIP(host)
+		return ip != nil && r.ipNet.Contains(ip)
+	default:
+		return host == r.host
+	}
+}
@@ -0,0 +1,425 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProxy_Validate(t *testing.T) {
+	for _, p := range []Proxy{
+		{},
+		{URL: "http://localhost:3128"},
+		{URL: "https://proxy.internal", Username: "user", Password: "pass"},
+		{URL: "socks5://localhost:1080", NoProxy: []string{"*", ".internal", "10.0.0.0/8", "localhost"}},
+	} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", p, err)
+		}
+	}
+
+	for _, p := range []Proxy{
+		{Username: "user"},
+		{URL: "ftp://localhost"},
+		{URL: "http://"},
+		{URL: "http://localhost", NoProxy: []string{""}},
+		{URL: "http://localhost", NoProxy: []string{"10.0.0.0/33"}},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("exp error for %+v, got nil", p)
+		}
+	}
+}
+
+func TestProxy_bypass(t *testing.T) {
+	p, err := Proxy{
+		URL:     "http://localhost:3128",
+		NoProxy: []string{".example.com", "10.0.0.0/8", "Internal", "::1"},
+	}.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		host string
+		exp  bool
+	}{
+		{host: "example.com", exp: true},
+		{host: "api.example.com:443", exp: true},
+		{host: "notexample.com", exp: false},
+		{host: "10.1.2.3:8080", exp: true},
+		{host: "11.1.2.3", exp: false},
+		{host: "internal:80", exp: true},
+		{host: "internal.com", exp: false},
+		{host: "[::1]:80", exp: true},
+	}
+
+	for _, tc := range testcases {
+		if got := p.bypass(tc.host); got != tc.exp {
+			t.Errorf("%s: exp %v, got %v", tc.host, tc.exp, got)
+		}
+	}
+}
+
+func TestRecorder_Record_proxy(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	tlsServer := httptest.NewTLSServer(noop)
+	defer tlsServer.Close()
+
+	httpServer := httptest.NewServer(noop)
+	defer httpServer.Close()
+
+	newRecorder := func(proxy Proxy) *Recorder {
+		return New(Config{
+			Requests:       2,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			TLS:            TLSConfig{InsecureSkipVerify: true},
+			Proxy:          proxy,
+		})
+	}
+
+	t.Run("tunnel https requests via CONNECT", func(t *testing.T) {
+		proxy := newConnectProxy(t, false)
+		r := newRecorder(Proxy{URL: proxy.url, Username: "user", Password: "pass"})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", tlsServer.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertProxyConnect(t, records)
+		// One tunnel for the ping, one for the virtual user.
+		if got := proxy.connects(); got != 2 {
+			t.Errorf("exp 2 CONNECT requests, got %d", got)
+		}
+		if exp, got := "Basic dXNlcjpwYXNz", proxy.authorization(); got != exp {
+			t.Errorf("exp Proxy-Authorization %q, got %q", exp, got)
+		}
+	})
+
+	t.Run("time CONNECT once via an https proxy", func(t *testing.T) {
+		proxy := newConnectProxy(t, true)
+		r := newRecorder(Proxy{URL: proxy.url})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", tlsServer.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertProxyConnect(t, records)
+		var names []string
+		for _, e := range records[0].Events {
+			if e.Name == "ProxyConnect" || e.Name == "TLSHandshakeStart" {
+				names = append(names, e.Name)
+			}
+		}
+		// The handshake with the proxy is not traced, only the one
+		// with the server through the tunnel.
+		if exp := []string{"ProxyConnect", "TLSHandshakeStart"}; !reflect.DeepEqual(names, exp) {
+			t.Errorf("records[0]: exp events %v, got %v", exp, names)
+		}
+		if got := proxy.connects(); got != 2 {
+			t.Errorf("exp 2 CONNECT requests, got %d", got)
+		}
+	})
+
+	t.Run("forward http requests", func(t *testing.T) {
+		proxy := newConnectProxy(t, false)
+		r := newRecorder(Proxy{URL: proxy.url})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", httpServer.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, rec := range records {
+			if rec.Error != "" {
+				t.Fatalf("records[%d]: unexpected error: %s", i, rec.Error)
+			}
+			if hasEvent(rec, "ProxyConnect") {
+				t.Errorf("records[%d]: exp no ProxyConnect event", i)
+			}
+		}
+		if got := proxy.forwarded(); got != 3 {
+			t.Errorf("exp 3 forwarded requests, got %d", got)
+		}
+	})
+
+	t.Run("tunnel requests via SOCKS5", func(t *testing.T) {
+		proxy := newSOCKS5Proxy(t, "user", "pass")
+		r := newRecorder(Proxy{URL: "socks5://user:pass@" + proxy.addr})
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", httpServer.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertProxyConnect(t, records)
+		if got := proxy.connects(); got != 2 {
+			t.Errorf("exp 2 SOCKS5 connections, got %d", got)
+		}
+	})
+
+	t.Run("send requests to no proxy hosts directly", func(t *testing.T) {
+		proxy := newConnectProxy(t, false)
+		r := newRecorder(Proxy{URL: proxy.url, NoProxy: []string{"127.0.0.1"}})
+
+		if _, err := r.Record(context.Background(), mustNewRequest("GET", tlsServer.URL)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := proxy.connects() + proxy.forwarded(); got != 0 {
+			t.Errorf("exp no proxied request, got %d", got)
+		}
+	})
+
+	t.Run("tunnel requests to resolved addresses", func(t *testing.T) {
+		for _, tc := range []struct {
+			label  string
+			server *httptest.Server
+			proxy  *testProxy
+			url    func(p *testProxy) string
+		}{
+			{
+				label:  "CONNECT",
+				server: tlsServer,
+				proxy:  newConnectProxy(t, false),
+				url:    func(p *testProxy) string { return p.url },
+			},
+			{
+				label:  "SOCKS5",
+				server: httpServer,
+				proxy:  newSOCKS5Proxy(t, "user", "pass"),
+				url:    func(p *testProxy) string { return "socks5://user:pass@" + p.addr },
+			},
+		} {
+			t.Run(tc.label, func(t *testing.T) {
+				r := newRecorder(Proxy{URL: tc.url(tc.proxy)})
+				// The host cannot be resolved, so the tunnel is only
+				// established if it targets the mapped address.
+				r.config.Dial = DialConfig{Resolve: map[string]string{
+					"benchttp.invalid": tc.server.Listener.Addr().String(),
+				}}
+				u := strings.Replace(tc.server.URL, "127.0.0.1", "benchttp.invalid", 1)
+
+				records, err := r.Record(context.Background(), mustNewRequest("GET", u))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				assertProxyConnect(t, records)
+			})
+		}
+	})
+
+	t.Run("return ErrConnection for invalid config", func(t *testing.T) {
+		r := newRecorder(Proxy{URL: "ftp://localhost"})
+
+		_, err := r.Record(context.Background(), mustNewRequest("GET", tlsServer.URL))
+		if !errors.Is(err, ErrConnection) {
+			t.Errorf("exp ErrConnection, got %v", err)
+		}
+	})
+}
+
+// helpers
+
+func assertProxyConnect(t *testing.T, records []Record) {
+	t.Helper()
+	assertNoRecordError(t, records)
+	// Only the first request opens a tunnel, the second one reuses it.
+	if !hasEvent(records[0], "ProxyConnect") {
+		t.Errorf("records[0]: exp ProxyConnect event, got %+v", records[0].Events)
+	}
+}
+
+func hasEvent(rec Record, name string) bool {
+	for _, e := range rec.Events {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// testProxy counts the requests it handled.
+type testProxy struct {
+	url  string
+	addr string
+
+	mu         sync.Mutex
+	nconnects  int
+	nforwarded int
+	auth       string
+}
+
+func (p *testProxy) connects() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nconnects
+}
+
+func (p *testProxy) forwarded() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nforwarded
+}
+
+func (p *testProxy) authorization() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.auth
+}
+
+// newConnectProxy returns a started HTTP proxy tunneling CONNECT
+// requests and forwarding the other ones, served over TLS if useTLS
+// is true.
+func newConnectProxy(t *testing.T, useTLS bool) *testProxy {
+	t.Helper()
+	p := &testProxy{}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.auth = r.Header.Get("Proxy-Authorization")
+		if r.Method != http.MethodConnect {
+			p.nforwarded++
+			p.mu.Unlock()
+			resp, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			io.Copy(w, resp.Body) //nolint:errcheck
+			return
+		}
+		p.nconnects++
+		p.mu.Unlock()
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")) //nolint:errcheck
+		pipe(conn, upstream)
+	}))
+	if useTLS {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	p.url = srv.URL
+	p.addr = srv.Listener.Addr().String()
+	return p
+}
+
+// newSOCKS5Proxy returns a started SOCKS5 proxy supporting the CONNECT
+// command with username/password authentication.
+func newSOCKS5Proxy(t *testing.T, username, password string) *testProxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	p := &testProxy{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				upstream, err := socks5Handshake(conn, username, password)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				p.mu.Lock()
+				p.nconnects++
+				p.mu.Unlock()
+				pipe(conn, upstream)
+			}()
+		}
+	}()
+	return p
+}
+
+// socks5Handshake performs the server side of a SOCKS5 handshake
+// on conn (RFC 1928, RFC 1929) and returns the connection
+// to the requested address.
+func socks5Handshake(conn net.Conn, username, password string) (net.Conn, error) {
+	br := bufio.NewReader(conn)
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		io.ReadFull(br, b) //nolint:errcheck
+		return b
+	}
+
+	// greeting: version, methods
+	read(int(read(2)[1]))
+	conn.Write([]byte{5, 2}) //nolint:errcheck // username/password
+
+	// authentication: version, username, password
+	read(1)
+	user := string(read(int(read(1)[0])))
+	pass := string(read(int(read(1)[0])))
+	if user != username || pass != password {
+		conn.Write([]byte{1, 1}) //nolint:errcheck
+		return nil, errors.New("invalid credentials")
+	}
+	conn.Write([]byte{1, 0}) //nolint:errcheck
+
+	// request: version, command, reserved, address type, address, port
+	header := read(4)
+	var host string
+	switch header[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	case 4:
+		host = net.IP(read(16)).String()
+	}
+	port := binary.BigEndian.Uint16(read(2))
+
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0}) //nolint:errcheck
+		return nil, err
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}) //nolint:errcheck
+	return upstream, nil
+}
+
+// pipe copies data between a and b until either is closed.
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b) //nolint:errcheck
+		a.Close()
+	}()
+	io.Copy(b, a) //nolint:errcheck
+	b.Close()
+}
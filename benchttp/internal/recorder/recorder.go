@@ -2,11 +2,13 @@ package recorder
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/benchttp/engine/benchttp/internal/render"
 	"github.com/benchttp/engine/internal/dispatcher"
 )
 
@@ -22,10 +24,59 @@ type Config struct {
 	Concurrency int
 	// Interval is the minimum duration between two non-concurrent requests.
 	Interval time.Duration
+	// Rate is the number of requests started per second. If set, requests
+	// are sent on a fixed timeline regardless of whether previous requests
+	// are done (open model), and Concurrency and Interval are ignored.
+	Rate int
 	// RequestTimeout is the timeout for each request sent.
 	RequestTimeout time.Duration
 	// GlobalTimeout is the timeout for the whole run.
 	GlobalTimeout time.Duration
+	// Duration is the duration of the run. If set, requests are sent
+	// until it is elapsed or Requests is reached.
+	Duration time.Duration
+	// Stages is the load profile of the run. If set, the level of load
+	// (Concurrency, or Rate in open model) is updated while running,
+	// starting from its configured value, and the run ends when all
+	// stages are done.
+	Stages []Stage
+	// Warmup is the duration from the start of the run during which
+	// the requests sent are marked as warm-up requests.
+	Warmup time.Duration
+	// WarmupRequests is the number of first requests sent that are
+	// marked as warm-up requests.
+	WarmupRequests int
+	// Seed is the seed of the random values generated by the templates
+	// of the requests, e.g. {{uuid}} or {{randInt 1 100}}, and by the
+	// Feeder. The values of each iteration are derived from Seed and
+	// the iteration index.
+	Seed int64
+	// Feeder provides rows of data to the templates of the requests.
+	Feeder Feeder
+	// Auth determines how requests are authenticated.
+	Auth Auth
+	// Signer signs each request just before it is sent, if set.
+	Signer Signer
+	// Protocol is the HTTP protocol used to send requests.
+	// Defaults to ProtocolAuto.
+	Protocol Protocol
+	// TLS configures the TLS connections of the requests.
+	TLS TLSConfig
+	// Dial configures how the connections of the requests are opened:
+	// host resolution overrides, DNS cache or Unix domain socket.
+	Dial DialConfig
+	// Proxy configures the proxy the requests are sent through.
+	// It is only supported by protocols auto and http1.
+	Proxy Proxy
+	// Checks are the validation rules of the responses. A response
+	// failing any of them is recorded as invalid.
+	Checks []Check
+	// VirtualUsers determines the state of each concurrent worker kept
+	// across its iterations: its connections and cookies.
+	VirtualUsers VirtualUsers
+	// AbortConditions are conditions on the latest results of the run
+	// that stop it early when any of them is met.
+	AbortConditions []AbortCondition
 	// OnProgress is called each time the requester Progress is updated.
 	// The requester Progress is updated each time a request is done,
 	// and every second concurrently.
@@ -36,13 +87,30 @@ type Config struct {
 // It must be initialized with New: it won't work otherwise.
 type Recorder struct {
 	records    []Record
+	iterations []IterationRecord
+	isScenario bool
+	numIter    int
 	runErr     error
 	start      time.Time
 	done       bool
 	onProgress func(Progress)
 
-	config       Config
+	abortMonitor   *abortMonitor
+	abort          *Abort
+	cancelDispatch context.CancelFunc
+
+	config Config
+	// newTransport returns the transport of a new virtual user.
 	newTransport func() http.RoundTripper
+	renderer     *render.Renderer
+	feeder       *feeder
+	auth         *authenticator
+	tlsConfig    *tls.Config
+	dialer       *dialer
+	proxy        *proxy
+	checks       []Check
+	workers      workerPool
+	users        *userPool
 
 	mu sync.RWMutex
 }
@@ -59,44 +127,164 @@ func New(cfg Config) *Recorder {
 		onProgress = func(Progress) {}
 	}
 
-	return &Recorder{
+	r := &Recorder{
 		records:    make([]Record, 0, recordsCap),
 		config:     cfg,
 		onProgress: onProgress,
-		newTransport: func() http.RoundTripper {
-			return newTracer()
-		},
+		renderer:   render.New(cfg.Seed),
+		feeder:     newFeeder(cfg.Feeder),
+		auth:       newAuthenticator(cfg.Auth, cfg.RequestTimeout),
+	}
+	r.newTransport = func() http.RoundTripper {
+		return newProtocolTransport(cfg.Protocol, r.tlsConfig, r.dialer, r.proxy)
 	}
+	return r
 }
 
 // Record clones and sends req n times, or until ctx is done or the global
 // timeout  is reached. It gathers the collected results into a Benchmark.
+// The templates of req are rendered for each request with the data
+// of the iteration (see iteration.data).
 func (r *Recorder) Record(ctx context.Context, req *http.Request) ([]Record, error) {
-	if err := r.ping(req); err != nil {
+	return r.RecordMix(ctx, []WeightedRequest{{Weight: 1, Request: req}})
+}
+
+// RecordMix behaves like Record, except that the requests sent are picked
+// from mix according to their weights. Each Record is named after
+// the request it results from.
+func (r *Recorder) RecordMix(ctx context.Context, mix []WeightedRequest) ([]Record, error) {
+	if err := r.prepare(ctx); err != nil {
+		return nil, err
+	}
+	for _, wr := range mix {
+		req, err := r.renderPing(ctx, wr.Request)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.ping(ctx, req); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrConnection, err)
+		}
+	}
+	return r.run(ctx, r.recordSingle(newRequestPicker(mix)))
+}
+
+// RecordScenario behaves like Record, except that each iteration runs
+// the steps of s in sequence. Each Record is named after the step
+// it results from, and the records of the iterations are available
+// via Iterations. Config.Requests is the number of iterations.
+func (r *Recorder) RecordScenario(ctx context.Context, s Scenario) ([]Record, error) {
+	r.isScenario = true
+	if err := r.prepare(ctx); err != nil {
+		return nil, err
+	}
+	s, err := s.compile()
+	if err != nil {
+		return nil, err
+	}
+	req, err := r.renderPing(ctx, s.Steps[0].Request)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ping(ctx, req); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrConnection, err)
 	}
+	return r.run(ctx, r.recordScenario(s))
+}
+
+// prepare compiles the checks, loads the TLS, dial and proxy configurations
+// and the credentials of the requests before the run.
+func (r *Recorder) prepare(ctx context.Context) error {
+	checks := make([]Check, len(r.config.Checks))
+	for i, c := range r.config.Checks {
+		compiled, err := c.compile()
+		if err != nil {
+			return fmt.Errorf("checks[%d]: %s", i, err)
+		}
+		checks[i] = compiled
+	}
+	r.checks = checks
+
+	tlsConfig, err := r.config.TLS.Load()
+	if err != nil {
+		return fmt.Errorf("%w: tls: %s", ErrConnection, err)
+	}
+	r.tlsConfig = tlsConfig
+
+	dialer, err := r.config.Dial.parse()
+	if err != nil {
+		return fmt.Errorf("%w: dial: %s", ErrConnection, err)
+	}
+	r.dialer = dialer
 
+	proxy, err := r.config.Proxy.parse()
+	if err != nil {
+		return fmt.Errorf("%w: proxy: %s", ErrConnection, err)
+	}
+	r.proxy = proxy
+
+	if err := r.auth.prepare(ctx); err != nil {
+		return fmt.Errorf("%w: %s", ErrAuthentication, err)
+	}
+	return nil
+}
+
+// run calls iterate for each iteration of the run as configured
+// by r.config. It returns the records collected.
+func (r *Recorder) run(ctx context.Context, iterate func(context.Context, iteration)) ([]Record, error) {
 	var (
 		errRun error
 
-		numWorker = r.config.Concurrency
-		maxIter   = r.config.Requests
-		timeout   = r.config.GlobalTimeout
-		interval  = r.config.Interval
+		maxIter  = r.config.Requests
+		timeout  = r.config.GlobalTimeout
+		interval = r.config.Interval
 	)
 
+	if r.config.Rate > 0 {
+		// Waiting between requests is meaningless in an open model.
+		interval = 0
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	dispatchCtx, cancelDispatch := r.dispatchContext(ctx)
+	defer cancelDispatch()
+
 	r.start = time.Now()
+	r.cancelDispatch = cancelDispatch
+	r.users = newUserPool(r.config.VirtualUsers, r.newTransport)
+	defer r.users.close()
+	if len(r.config.AbortConditions) > 0 {
+		r.abortMonitor = newAbortMonitor(r.config.AbortConditions, r.start)
+	}
 	go r.tickProgress()
 
-	err := dispatcher.
-		New(numWorker).
-		Do(ctx, maxIter, r.recordSingle(req, interval))
+	d := r.newDispatcher()
+	if len(r.config.Stages) > 0 {
+		go r.scale(dispatchCtx, d)
+	}
+
+	err := d.Do(dispatchCtx, maxIter, func(dispatchCtx context.Context) {
+		it := r.newIteration()
+		defer r.workers.release(it.worker)
+
+		// The end of the dispatch does not interrupt the iterations
+		// in progress: the slowest requests, the most likely to be
+		// in flight when the run reaches its duration, are recorded
+		// within the limits of the request and global timeouts.
+		iterate(ctx, it)
+		sleep(dispatchCtx, interval)
+	})
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		// The run reached its duration before the global timeout.
+		err = nil
+	}
+	if r.Aborted() != nil && ctx.Err() == nil {
+		err = ErrAborted
+	}
 
 	switch err {
-	case nil, context.DeadlineExceeded:
+	case nil, context.DeadlineExceeded, ErrAborted:
 		r.end(err)
 	case context.Canceled:
 		r.end(err)
@@ -108,13 +296,54 @@ func (r *Recorder) Record(ctx context.Context, req *http.Request) ([]Record, err
 	return r.records, errRun
 }
 
-func (r *Recorder) ping(req *http.Request) error {
-	client := newClient(r.newTransport(), r.config.RequestTimeout)
+// dispatchContext returns a copy of ctx that is done when the run
+// must stop sending requests: when its duration is reached if it has one,
+// or when ctx is done.
+func (r *Recorder) dispatchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := r.runDuration(); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// runDuration returns the duration after which the run ends regardless
+// of the global timeout: the shortest of Config.Duration and the total
+// duration of Config.Stages, or 0 if none is set.
+func (r *Recorder) runDuration() time.Duration {
+	d := r.config.Duration
+	if len(r.config.Stages) > 0 {
+		if sd := stagesDuration(r.config.Stages); d == 0 || sd < d {
+			d = sd
+		}
+	}
+	return d
+}
+
+// newDispatcher returns the dispatcher.Dispatcher matching r.config:
+// a constant arrival rate dispatcher if Config.Rate is set,
+// else a dispatcher limiting the number of concurrent requests.
+func (r *Recorder) newDispatcher() dispatcher.Dispatcher {
+	if r.config.Rate > 0 {
+		return dispatcher.NewRate(r.config.Rate)
+	}
+	return dispatcher.New(r.config.Concurrency)
+}
+
+func (r *Recorder) ping(ctx context.Context, req *http.Request) error {
+	transport := r.newTransport()
+	client := newClient(transport, r.config.RequestTimeout)
+	req, err := cloneRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := r.auth.authorize(ctx, req); err != nil {
+		return err
+	}
 	resp, err := client.Do(req)
 	if resp != nil {
 		resp.Body.Close()
 	}
-	client.CloseIdleConnections()
+	closeIdleConnections(transport)
 	return err
 }
 
@@ -122,59 +351,264 @@ func (r *Recorder) ping(req *http.Request) error {
 // empty string, the HTTP call failed somewhere between sending the request
 // to decoding the response body. In that cas invalidating the entire response,
 // as it is not a remote server error.
+// If Record.Canceled is true, the request was interrupted because the run
+// was canceled or timed out, and Record.Error is empty.
 type Record struct {
-	Time   time.Duration
-	Code   int
-	Bytes  int
-	Error  string
-	Events []Event
-}
-
-func (r *Recorder) recordSingle(req *http.Request, interval time.Duration) func() {
-	return func() {
-		// We need new client and request instances each call to this function
-		// to make it safe for concurrent use.
-		client := newClient(r.newTransport(), r.config.RequestTimeout)
-		newReq := cloneRequest(req)
-
-		// Send request
-		resp, err := client.Do(newReq)
-		if err != nil {
-			r.appendRecord(Record{Error: recordErr(err)})
-			return
-		}
+	// Name is the name of the WeightedRequest the Record results from.
+	Name string
+	// Start is the time the request started to be processed,
+	// including the rendering of its templates.
+	Start time.Time
+	Time  time.Duration
+	Code  int
+	// Proto is the protocol of the response, e.g. "HTTP/1.1"
+	// or "HTTP/2.0". It is empty if no response was received.
+	Proto string
+	// TLSVersion, TLSCipherSuite and TLSResumed describe the TLS
+	// handshake of the request, e.g. "TLS 1.3", "TLS_AES_128_GCM_SHA256"
+	// and whether a previous session was resumed. They are empty
+	// if no handshake was done, e.g. if a connection was reused.
+	TLSVersion     string
+	TLSCipherSuite string
+	TLSResumed     bool
+	// ConnReused, ConnWasIdle and ConnIdleTime describe the connection
+	// the request was sent on: whether it was previously used for another
+	// request, and if so whether and how long it was idle before.
+	ConnReused   bool
+	ConnWasIdle  bool
+	ConnIdleTime time.Duration
+	// Bytes is the size of the response body received and BytesSent
+	// the size of the request body sent.
+	Bytes     int
+	BytesSent int
+	Error     string
+	// InvalidReason is the reason of the first of Config.Checks the
+	// response failed, or empty string if it passed all of them.
+	// It is empty if the request failed.
+	InvalidReason string
+	// InvalidDetail describes why the response failed the check
+	// of InvalidReason, prefixed with InvalidReason, e.g.
+	// "status: status 500: want 2xx".
+	InvalidDetail string
+	Canceled      bool
+	Events        []Event
+	// Phases are the durations of the phases of the request,
+	// derived from Events.
+	Phases Phases
+	// SignTime is the time spent signing the request with Config.Signer.
+	// It is not included in Time.
+	SignTime time.Duration
+	// Warmup is true if the request was sent during the warm-up
+	// of the run, as defined by Config.Warmup and Config.WarmupRequests.
+	Warmup bool
+}
+
+// recordSingle returns an iteration callback sending a single request
+// picked from the mix of picker.
+func (r *Recorder) recordSingle(picker *requestPicker) func(context.Context, iteration) {
+	return func(ctx context.Context, it iteration) {
+		wr := picker.next()
+
+		start := time.Now()
+		rec := r.renderAndSend(ctx, it, wr.Request)
+		rec.Start = start
+		rec.Name = wr.Name
+		rec.Warmup = it.warmup
+		r.appendRecord(rec)
+	}
+}
+
+// renderAndSend renders the templates of req with the data of it,
+// then sends it as the user of it and returns the resulting Record.
+func (r *Recorder) renderAndSend(ctx context.Context, it iteration, req *http.Request) Record {
+	rendered, err := it.renderer.Request(ctx, req, it.data())
+	if err != nil {
+		return Record{Error: renderErr(err)}
+	}
+	rec, _ := r.send(ctx, it.user, rendered)
+	return rec
+}
 
-		// Read and close response body
-		body, err := readClose(resp)
+// renderPing returns req with its templates rendered with the data
+// and the seed of the first iteration and the first row of the feeder.
+// It returns an error wrapping ErrRender if req cannot be rendered,
+// as none of the requests of the run could be.
+func (r *Recorder) renderPing(ctx context.Context, req *http.Request) (*http.Request, error) {
+	it := iteration{row: r.feeder.first()}
+	renderer := r.renderer.WithSeed(iterationSeed(r.config.Seed, 0))
+	rendered, err := renderer.Request(ctx, req, it.data())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRender, err)
+	}
+	return rendered, nil
+}
+
+// send sends a clone of req bound to ctx as user, authenticated as defined
+// by Config.Auth, and returns the resulting Record and the received
+// response. If the response is 401 Unauthorized and the credentials
+// can be renewed, the request is sent again once with new credentials
+// and only the second attempt is recorded. The received response is
+// checked against Config.Checks.
+func (r *Recorder) send(ctx context.Context, user *virtualUser, req *http.Request) (Record, response) {
+	rec, resp, newReq := r.sendAuthorized(ctx, user, req)
+	if rec.Code == http.StatusUnauthorized && r.auth.reject(newReq) {
+		rec, resp, _ = r.sendAuthorized(ctx, user, req)
+	}
+	if rec.Error == "" && !rec.Canceled {
+		reason, err := r.checkResponse(rec.Code, resp)
 		if err != nil {
-			r.appendRecord(Record{Error: recordErr(err)})
-			return
+			rec.InvalidReason, rec.InvalidDetail = reason, err.Error()
 		}
+	}
+	return rec, resp
+}
 
-		// Retrieve tracer events and append BodyRead event
-		events := []Event{}
-		if reqtracer, ok := client.Transport.(*tracer); ok {
-			r.mu.Lock()
-			reqtracer.addEventBodyRead()
-			r.mu.Unlock()
-			events = reqtracer.events
+// sendAuthorized sends an authorized clone of req bound to ctx as user and
+// returns the resulting Record, the received response and the request sent.
+func (r *Recorder) sendAuthorized(ctx context.Context, user *virtualUser, req *http.Request) (Record, response, *http.Request) {
+	newReq, err := cloneRequest(ctx, req)
+	if err != nil {
+		return Record{Error: recordErr(err)}, response{}, req
+	}
+	if err := r.auth.authorize(ctx, newReq); err != nil {
+		if ctx.Err() != nil {
+			return Record{Canceled: true}, response{}, newReq
 		}
+		return Record{Error: authErr(err)}, response{}, newReq
+	}
+	signTime, err := r.sign(newReq)
+	if err != nil {
+		return Record{Error: signErr(err), SignTime: signTime}, response{}, newReq
+	}
+	rec, resp := r.sendRequest(ctx, user, newReq)
+	rec.SignTime = signTime
+	return rec, resp, newReq
+}
 
-		r.appendRecord(Record{
-			Code:   resp.StatusCode,
-			Time:   eventsTotalTime(events),
-			Bytes:  len(body),
-			Events: events,
-		})
+// sign signs req with Config.Signer if set, and returns the time spent.
+func (r *Recorder) sign(req *http.Request) (time.Duration, error) {
+	if r.config.Signer == nil {
+		return 0, nil
+	}
+	start := time.Now()
+	err := r.config.Signer.Sign(req, start)
+	return time.Since(start), err
+}
 
-		time.Sleep(interval)
+// sendRequest sends req with the client of user and returns
+// the resulting Record and the received response.
+func (r *Recorder) sendRequest(ctx context.Context, user *virtualUser, req *http.Request) (Record, response) {
+	// The client and tracer are specific to this request, only the
+	// transport and cookies are shared by the requests of user.
+	client, reqtracer := user.client(r.config.RequestTimeout)
+	user.prepare(req)
+	defer user.done()
+
+	sentBody := countBody(req)
+
+	// Send request
+	resp, err := client.Do(req)
+	if err != nil {
+		return failedRecord(ctx, err), response{}
 	}
+
+	// Read and close response body
+	body, err := readClose(resp)
+	if err != nil {
+		return failedRecord(ctx, err), response{}
+	}
+
+	// Retrieve tracer events and append BodyRead event
+	reqtracer.addEventBodyRead()
+	events := reqtracer.events
+
+	rec := Record{
+		Code:      resp.StatusCode,
+		Proto:     resp.Proto,
+		Time:      eventsTotalTime(events),
+		Bytes:     len(body),
+		BytesSent: sentBody.count(),
+		Events:    events,
+		Phases:    newPhases(events),
+
+		ConnReused:   reqtracer.conn.Reused,
+		ConnWasIdle:  reqtracer.conn.WasIdle,
+		ConnIdleTime: reqtracer.conn.IdleTime,
+	}
+	if state := reqtracer.tls; state != nil {
+		rec.TLSVersion = tlsVersionName(state.Version)
+		rec.TLSCipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		rec.TLSResumed = state.DidResume
+	}
+	return rec, response{header: resp.Header, body: body}
+}
+
+// failedRecord returns the Record of a request that failed with err.
+// The Record is marked as canceled if the failure is caused by ctx
+// being done rather than by the request itself.
+func failedRecord(ctx context.Context, err error) Record {
+	if ctx.Err() != nil {
+		return Record{Canceled: true}
+	}
+	return Record{Error: recordErr(err)}
+}
+
+// newIteration returns the next iteration, starting at index 0.
+// Its worker must be released when the iteration is done.
+func (r *Recorder) newIteration() iteration {
+	r.mu.Lock()
+	i := r.numIter
+	r.numIter++
+	r.mu.Unlock()
+
+	it := iteration{
+		index:  i,
+		worker: r.workers.acquire(),
+		warmup: r.isWarmup(i),
+		seed:   iterationSeed(r.config.Seed, i),
+	}
+	it.row = r.feeder.row(it)
+	it.renderer = r.renderer.WithSeed(it.seed)
+	it.user = r.users.get(it.worker)
+	return it
 }
 
+// isWarmup returns true if the iteration of index i
+// is part of the warm-up of the run.
+func (r *Recorder) isWarmup(i int) bool {
+	return i < r.config.WarmupRequests || time.Since(r.start) < r.config.Warmup
+}
+
+// appendRecord appends rec to the records of the run, then stops the run
+// if rec meets an abort condition. The conditions are evaluated outside
+// of r.mu so that they do not block the other iterations.
 func (r *Recorder) appendRecord(rec Record) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	r.records = append(r.records, rec)
+	r.mu.Unlock()
+
+	if r.abortMonitor == nil {
+		return
+	}
+	abort := r.abortMonitor.add(rec, time.Now())
+	if abort == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.abort == nil {
+		r.abort = abort
+		r.cancelDispatch()
+	}
+}
+
+// Aborted returns the Abort that stopped the run early,
+// or nil if no abort condition was met.
+func (r *Recorder) Aborted() *Abort {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.abort
 }
 
 // tickProgress refreshes the Progress every second.
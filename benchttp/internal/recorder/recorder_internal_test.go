@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"testing"
@@ -78,6 +80,37 @@ func TestRecorder_Run(t *testing.T) {
 		t.Log(recs)
 	})
 
+	t.Run("record requests whose body cannot be cloned", func(t *testing.T) {
+		r := withNoopTransport(New(Config{
+			Requests:       1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		}))
+
+		req := validRequestWithBody([]byte("body"))
+		numCalls := 0
+		req.GetBody = func() (io.ReadCloser, error) {
+			// The first call is made by the ping.
+			if numCalls++; numCalls > 1 {
+				return nil, errTest
+			}
+			return io.NopCloser(bytes.NewReader([]byte("body"))), nil
+		}
+
+		recs, err := r.Record(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(recs) != 1 {
+			t.Fatalf("unexpected records length: exp 1, got %d", len(recs))
+		}
+		if exp := recordErr(fmt.Errorf("request body: %w", errTest)); recs[0].Error != exp {
+			t.Errorf("unexpected record error:\nexp %q\ngot %q", exp, recs[0].Error)
+		}
+	})
+
 	t.Run("happy path", func(t *testing.T) {
 		r := withNoopTransport(New(Config{
 			Requests:       1,
@@ -95,12 +128,187 @@ func TestRecorder_Run(t *testing.T) {
 		}
 
 		if len(recs) != 1 {
-			t.Errorf("unexpected Report.Length: exp 1, got %d", len(recs))
+			t.Fatalf("unexpected Report.Length: exp 1, got %d", len(recs))
+		}
+
+		if recs[0].Start.IsZero() {
+			t.Error("exp record start time to be set")
 		}
 
 		t.Log(recs)
 	})
 
+	t.Run("record body sizes", func(t *testing.T) {
+		srv := newConnServer(t, func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(w, r.Body)   //nolint:errcheck
+			w.Write([]byte("!")) //nolint:errcheck
+		})
+		r := New(Config{
+			Requests:       2,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		})
+
+		req, _ := http.NewRequest("POST", srv.URL, bytes.NewReader([]byte("hello")))
+		recs, err := r.Record(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertNoRecordError(t, recs)
+		for i, rec := range recs {
+			if rec.BytesSent != 5 || rec.Bytes != 6 {
+				t.Errorf("records[%d]: exp 5 bytes sent and 6 received, got %d and %d",
+					i, rec.BytesSent, rec.Bytes)
+			}
+		}
+	})
+
+	t.Run("cancel in-flight requests with the run", func(t *testing.T) {
+		const globalTimeout = 100 * time.Millisecond
+
+		r := withBlockingTransport(New(Config{
+			Requests:       2,
+			Concurrency:    2,
+			RequestTimeout: 3 * time.Second,
+			GlobalTimeout:  globalTimeout,
+		}))
+
+		start := time.Now()
+		recs, err := r.Record(context.Background(), validRequest())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if elapsed, maxElapsed := time.Since(start), globalTimeout+50*time.Millisecond; elapsed > maxElapsed {
+			t.Errorf("unexpected duration: exp < %v, got %v", maxElapsed, elapsed)
+		}
+
+		if len(recs) != 2 {
+			t.Fatalf("unexpected records length: exp 2, got %d", len(recs))
+		}
+
+		for i, rec := range recs {
+			if !rec.Canceled || rec.Error != "" {
+				t.Errorf("record %d: exp canceled without error, got %+v", i, rec)
+			}
+		}
+	})
+
+	t.Run("stop when duration is reached", func(t *testing.T) {
+		const duration = 100 * time.Millisecond
+
+		r := withNoopTransport(New(Config{
+			Requests:       -1,
+			Concurrency:    1,
+			Duration:       duration,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		}))
+
+		start := time.Now()
+		if _, err := r.Record(context.Background(), validRequest()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if elapsed, maxElapsed := time.Since(start), duration+50*time.Millisecond; elapsed > maxElapsed {
+			t.Errorf("unexpected duration: exp < %v, got %v", maxElapsed, elapsed)
+		}
+
+		if status := r.Progress().Status(); status != StatusDone {
+			t.Errorf("unexpected status: exp %s, got %s", StatusDone, status)
+		}
+	})
+
+	t.Run("complete in-flight requests when duration is reached", func(t *testing.T) {
+		const (
+			duration = 100 * time.Millisecond
+			delay    = 150 * time.Millisecond
+		)
+
+		r := New(Config{
+			Requests:       -1,
+			Concurrency:    2,
+			Duration:       duration,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		})
+		r.newTransport = func() http.RoundTripper {
+			return delayTransport{delay: delay}
+		}
+
+		recs, err := r.Record(context.Background(), validRequest())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(recs) != 2 {
+			t.Fatalf("unexpected records length: exp 2, got %d", len(recs))
+		}
+		for i, rec := range recs {
+			if rec.Canceled || rec.Error != "" {
+				t.Errorf("record %d: exp completed request, got %+v", i, rec)
+			}
+			if rec.Time < delay {
+				t.Errorf("record %d: exp time >= %v, got %v", i, delay, rec.Time)
+			}
+		}
+	})
+
+	t.Run("mark warm-up records", func(t *testing.T) {
+		const (
+			requests       = 10
+			warmupRequests = 3
+		)
+
+		r := withNoopTransport(New(Config{
+			Requests:       requests,
+			Concurrency:    1,
+			WarmupRequests: warmupRequests,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		}))
+
+		recs, err := r.Record(context.Background(), validRequest())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		gotWarmup := 0
+		for _, rec := range recs {
+			if rec.Warmup {
+				gotWarmup++
+			}
+		}
+
+		if len(recs) != requests || gotWarmup != warmupRequests {
+			t.Errorf(
+				"unexpected warm-up records: exp %d/%d, got %d/%d",
+				warmupRequests, requests, gotWarmup, len(recs),
+			)
+		}
+	})
+
+	t.Run("use rate", func(t *testing.T) {
+		r := withNoopTransport(New(Config{
+			Requests:       10,
+			Concurrency:    0, // ignored in open model
+			Rate:           1000,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		}))
+
+		recs, err := r.Record(context.Background(), validRequest())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(recs) != 10 {
+			t.Errorf("unexpected records length: exp 10, got %d", len(recs))
+		}
+	})
+
 	t.Run("use interval", func(t *testing.T) {
 		const (
 			requests    = 12
@@ -202,6 +410,47 @@ func withNoopTransport(req *Recorder) *Recorder {
 	return withCallbackTransport(req, func() {})
 }
 
+// blockingTransport responds immediately to the first request,
+// and blocks until the request context is done for the next ones.
+type blockingTransport struct {
+	mu       sync.Mutex
+	pingDone bool
+}
+
+func (t *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	isPing := !t.pingDone
+	t.pingDone = true
+	t.mu.Unlock()
+
+	if isPing {
+		return &http.Response{}, nil
+	}
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+// delayTransport responds after delay, unless the request context
+// is done before.
+type delayTransport struct{ delay time.Duration }
+
+func (t delayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-time.After(t.delay):
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func withBlockingTransport(req *Recorder) *Recorder {
+	transport := &blockingTransport{}
+	req.newTransport = func() http.RoundTripper {
+		return transport
+	}
+	return req
+}
+
 type errTransport struct{}
 
 func (errTransport) RoundTrip(*http.Request) (*http.Response, error) {
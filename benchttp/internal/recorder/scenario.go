@@ -0,0 +1,293 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scenario is an ordered list of steps that are run in sequence
+// as a single iteration, e.g. a user journey. Values extracted from
+// the response of a step are stored in variables that the requests
+// of the next steps can reference, e.g. "Bearer {{.token}}".
+type Scenario struct {
+	Steps []Step
+}
+
+// Step is a request of a Scenario.
+type Step struct {
+	// Name identifies the step in the records and the metrics.
+	Name string
+	// Request is the request sent. Its URL path and query, header values
+	// and template body may contain templates referencing the variables
+	// set by the previous steps.
+	Request *http.Request
+	// Extractors set variables from the response of the step.
+	Extractors []Extractor
+}
+
+// ExtractorType is the source of the value of an Extractor.
+type ExtractorType string
+
+const (
+	// ExtractJSON extracts the value at a JSON pointer (RFC 6901)
+	// from the response body, e.g. "/data/token".
+	ExtractJSON ExtractorType = "json"
+	// ExtractRegex extracts the first submatch of a regular expression
+	// in the response body, or the whole match if it has no group.
+	ExtractRegex ExtractorType = "regex"
+	// ExtractHeader extracts the value of a response header.
+	ExtractHeader ExtractorType = "header"
+)
+
+// Extractor extracts a value from the response of a Step
+// and stores it in the variable Var.
+type Extractor struct {
+	Var        string
+	Type       ExtractorType
+	Expression string
+
+	// rgx is the compiled form of Expression, set by compile.
+	rgx *regexp.Regexp
+}
+
+// IterationRecord is the summary of an iteration of a Scenario.
+// If Error is not empty, a step failed and the next steps
+// were not run.
+type IterationRecord struct {
+	Time     time.Duration
+	Error    string
+	Canceled bool
+	// Warmup is true if the iteration was run during the warm-up
+	// of the run, as defined by Config.Warmup and Config.WarmupRequests.
+	Warmup bool
+}
+
+// response is the received part of an exchange that is not kept
+// in a Record.
+type response struct {
+	header http.Header
+	body   []byte
+}
+
+// recordScenario returns an iteration callback running the steps
+// of s in sequence. Each step results in a Record named after it.
+func (r *Recorder) recordScenario(s Scenario) func(context.Context, iteration) {
+	return func(ctx context.Context, it iteration) {
+		start := time.Now()
+		iter := IterationRecord{Warmup: it.warmup}
+		vars := it.data()
+
+		for _, step := range s.Steps {
+			stepStart := time.Now()
+			rec := r.runStep(ctx, it, step, vars)
+			rec.Start = stepStart
+			rec.Name = step.Name
+			rec.Warmup = it.warmup
+			r.appendRecord(rec)
+
+			if rec.Canceled {
+				iter.Canceled = true
+				break
+			}
+			if rec.Error != "" {
+				iter.Error = fmt.Sprintf("step %s: %s", step.Name, rec.Error)
+				break
+			}
+		}
+
+		iter.Time = time.Since(start)
+		r.appendIteration(iter)
+	}
+}
+
+// runStep sends the request of step rendered with vars by the renderer
+// of it as the user of it, then stores the extracted values into vars.
+// It returns the resulting Record.
+func (r *Recorder) runStep(ctx context.Context, it iteration, step Step, vars map[string]string) Record {
+	req, err := it.renderer.Request(ctx, step.Request, vars)
+	if err != nil {
+		return Record{Error: renderErr(err)}
+	}
+
+	rec, resp := r.send(ctx, it.user, req)
+	if rec.Error != "" || rec.Canceled {
+		return rec
+	}
+
+	for _, e := range step.Extractors {
+		v, err := e.extract(resp)
+		if err != nil {
+			rec.Error = extractErr(e, err)
+			return rec
+		}
+		vars[e.Var] = v
+	}
+
+	return rec
+}
+
+// compile returns a copy of s with the expressions of its extractors
+// compiled, or a non-nil error if any of them cannot be used
+// to extract values.
+func (s Scenario) compile() (Scenario, error) {
+	steps := make([]Step, len(s.Steps))
+	for i, step := range s.Steps {
+		extractors := make([]Extractor, len(step.Extractors))
+		for j, e := range step.Extractors {
+			compiled, err := e.compile()
+			if err != nil {
+				return s, fmt.Errorf("steps[%d].extractors[%d]: %s", i, j, err)
+			}
+			extractors[j] = compiled
+		}
+		step.Extractors = extractors
+		steps[i] = step
+	}
+	s.Steps = steps
+	return s, nil
+}
+
+// extract returns the value extracted from resp.
+// e must be compiled.
+func (e Extractor) extract(resp response) (string, error) {
+	switch e.Type {
+	case ExtractJSON:
+		return extractJSON(resp.body, e.Expression)
+	case ExtractRegex:
+		return extractRegex(resp.body, e.rgx)
+	case ExtractHeader:
+		values := resp.header.Values(e.Expression)
+		if len(values) == 0 {
+			return "", errors.New("header not found")
+		}
+		return values[0], nil
+	}
+	return "", fmt.Errorf("unknown extractor type: %q", e.Type)
+}
+
+// extractJSON returns the value at pointer in the JSON document body.
+// String values are returned as is, other values JSON-encoded.
+func extractJSON(body []byte, pointer string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return "", err
+	}
+
+	v, err := resolveJSONPointer(v, pointer)
+	if err != nil {
+		return "", err
+	}
+
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// resolveJSONPointer returns the value at pointer in the decoded
+// JSON document doc, as specified by RFC 6901.
+func resolveJSONPointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: want leading /", pointer)
+	}
+
+	current := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%s: key %q not found", pointer, token)
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%s: index %q out of range", pointer, token)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("%s: %q not found", pointer, token)
+		}
+	}
+	return current, nil
+}
+
+// extractRegex returns the first submatch of rgx in body,
+// or the whole match if rgx has no group.
+func extractRegex(body []byte, rgx *regexp.Regexp) (string, error) {
+	matches := rgx.FindSubmatch(body)
+	switch {
+	case matches == nil:
+		return "", errors.New("no match")
+	case len(matches) > 1:
+		return string(matches[1]), nil
+	default:
+		return string(matches[0]), nil
+	}
+}
+
+// Validate returns a non-nil error if e cannot be used to extract values.
+func (e Extractor) Validate() error {
+	_, err := e.compile()
+	return err
+}
+
+// compile returns a copy of e with its expression compiled, or a non-nil
+// error if e cannot be used to extract values. Only compiled extractors
+// can extract values.
+func (e Extractor) compile() (Extractor, error) {
+	if e.Var == dataIteration || e.Var == dataWorker {
+		return e, fmt.Errorf("var %q: reserved name", e.Var)
+	}
+
+	var err error
+	switch e.Type {
+	case ExtractJSON:
+		if e.Expression != "" && !strings.HasPrefix(e.Expression, "/") {
+			return e, fmt.Errorf("invalid JSON pointer %q: want leading /", e.Expression)
+		}
+	case ExtractRegex:
+		e.rgx, err = regexp.Compile(e.Expression)
+	case ExtractHeader:
+		if e.Expression == "" {
+			return e, errors.New("empty header name")
+		}
+	default:
+		return e, fmt.Errorf(
+			"unknown type %q: want %q, %q or %q",
+			e.Type, ExtractJSON, ExtractRegex, ExtractHeader,
+		)
+	}
+	return e, err
+}
+
+func (r *Recorder) appendIteration(iter IterationRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.iterations = append(r.iterations, iter)
+}
+
+// Iterations returns the records of the iterations of the Scenario
+// run by RecordScenario.
+func (r *Recorder) Iterations() []IterationRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.iterations
+}
@@ -0,0 +1,207 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractor_extract(t *testing.T) {
+	resp := response{
+		header: http.Header{"X-Request-Id": {"abc"}},
+		body:   []byte(`{"data":{"token":"t0k3n","ids":[12,34],"a/b":true}}`),
+	}
+
+	for _, tc := range []struct {
+		name      string
+		extractor Extractor
+		exp       string
+		expErr    bool
+	}{
+		{
+			name:      "json string",
+			extractor: Extractor{Type: ExtractJSON, Expression: "/data/token"},
+			exp:       "t0k3n",
+		},
+		{
+			name:      "json array index",
+			extractor: Extractor{Type: ExtractJSON, Expression: "/data/ids/1"},
+			exp:       "34",
+		},
+		{
+			name:      "json escaped key",
+			extractor: Extractor{Type: ExtractJSON, Expression: "/data/a~1b"},
+			exp:       "true",
+		},
+		{
+			name:      "json missing key",
+			extractor: Extractor{Type: ExtractJSON, Expression: "/data/nope"},
+			expErr:    true,
+		},
+		{
+			name:      "regex submatch",
+			extractor: Extractor{Type: ExtractRegex, Expression: `"token":"(\w+)"`},
+			exp:       "t0k3n",
+		},
+		{
+			name:      "regex whole match",
+			extractor: Extractor{Type: ExtractRegex, Expression: `t0k\d+n`},
+			exp:       "t0k3n",
+		},
+		{
+			name:      "regex no match",
+			extractor: Extractor{Type: ExtractRegex, Expression: `nope`},
+			expErr:    true,
+		},
+		{
+			name:      "header",
+			extractor: Extractor{Type: ExtractHeader, Expression: "x-request-id"},
+			exp:       "abc",
+		},
+		{
+			name:      "missing header",
+			extractor: Extractor{Type: ExtractHeader, Expression: "X-Nope"},
+			expErr:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := tc.extractor.compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			got, err := e.extract(resp)
+			if gotErr := err != nil; gotErr != tc.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.exp {
+				t.Errorf("exp %q, got %q", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestRecorder_RecordScenario(t *testing.T) {
+	scenario := Scenario{Steps: []Step{
+		{
+			Name:       "login",
+			Request:    mustNewRequest("POST", validURI+"/login"),
+			Extractors: []Extractor{{Var: "token", Type: ExtractJSON, Expression: "/token"}},
+		},
+		{
+			Name:       "orders",
+			Request:    mustNewRequest("GET", validURI+"/orders?token={{.token}}"),
+			Extractors: []Extractor{{Var: "id", Type: ExtractRegex, Expression: `"id":(\d+)`}},
+		},
+		{
+			Name:    "order",
+			Request: mustNewRequest("GET", validURI+"/orders/{{.id}}?token={{.token}}"),
+		},
+	}}
+
+	gotPaths := make(chan string, 10)
+	r := withHandlerTransport(New(Config{
+		Requests:       2,
+		Concurrency:    1,
+		RequestTimeout: 1 * time.Second,
+		GlobalTimeout:  3 * time.Second,
+	}), http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotPaths <- req.URL.RequestURI()
+		switch req.URL.Path {
+		case "/login":
+			w.Write([]byte(`{"token":"abc"}`)) //nolint:errcheck
+		case "/orders":
+			w.Write([]byte(`[{"id":42}]`)) //nolint:errcheck
+		}
+	}))
+
+	recs, err := r.RecordScenario(context.Background(), scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(gotPaths)
+
+	if len(recs) != 6 {
+		t.Errorf("unexpected records length: exp 6, got %d", len(recs))
+	}
+	for _, rec := range recs {
+		if rec.Error != "" {
+			t.Errorf("unexpected record error: %s", rec.Error)
+		}
+	}
+
+	if iters := r.Iterations(); len(iters) != 2 {
+		t.Errorf("unexpected iterations length: exp 2, got %d", len(iters))
+	}
+
+	<-gotPaths // ping
+	for _, exp := range []string{"/login", "/orders?token=abc", "/orders/42?token=abc"} {
+		if got := <-gotPaths; got != exp {
+			t.Errorf("unexpected request: exp %s, got %s", exp, got)
+		}
+	}
+
+	t.Run("stop iteration on step failure", func(t *testing.T) {
+		r := withHandlerTransport(New(Config{
+			Requests:       1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+		}), http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		recs, err := r.RecordScenario(context.Background(), scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(recs) != 1 || recs[0].Error == "" {
+			t.Errorf("exp a single failed record, got %+v", recs)
+		}
+
+		iters := r.Iterations()
+		if len(iters) != 1 || iters[0].Error == "" {
+			t.Errorf("exp a single failed iteration, got %+v", iters)
+		}
+	})
+
+	t.Run("return error for invalid extractors", func(t *testing.T) {
+		r := New(Config{Requests: 1, Concurrency: 1, RequestTimeout: time.Second, GlobalTimeout: time.Second})
+		invalid := Scenario{Steps: []Step{{
+			Name:       "login",
+			Request:    mustNewRequest("GET", validURI),
+			Extractors: []Extractor{{Var: "token", Type: ExtractRegex, Expression: "("}},
+		}}}
+
+		_, err := r.RecordScenario(context.Background(), invalid)
+		if err == nil || !strings.HasPrefix(err.Error(), "steps[0].extractors[0]: ") {
+			t.Errorf("exp extractor compile error, got %v", err)
+		}
+	})
+}
+
+// handlerTransport is a http.RoundTripper that serves requests
+// with an http.Handler.
+type handlerTransport struct{ handler http.Handler }
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	t.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+func withHandlerTransport(req *Recorder, handler http.Handler) *Recorder {
+	req.newTransport = func() http.RoundTripper {
+		return handlerTransport{handler: handler}
+	}
+	return req
+}
+
+func mustNewRequest(method, uri string) *http.Request {
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
@@ -0,0 +1,264 @@
+package recorder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer signs requests, e.g. by setting a header computed from their
+// content. Sign is called for each request sent, after its templates
+// are rendered and its authentication is set, with t the signing time.
+// It must be safe for concurrent use.
+//
+// The time spent signing is recorded in Record.SignTime and is not
+// part of Record.Time.
+type Signer interface {
+	Sign(req *http.Request, t time.Time) error
+}
+
+// Default headers set by HMACSigner.
+const (
+	DefaultHMACHeader          = "X-Signature"
+	DefaultHMACTimestampHeader = "X-Timestamp"
+)
+
+// HMACSigner is a Signer computing the HMAC-SHA256 of the request with
+// Secret as the key, over the following string:
+//
+//	METHOD + "\n" + PATH?QUERY + "\n" + TIMESTAMP + "\n" + hex(sha256(BODY))
+//
+// with TIMESTAMP the signing time as Unix seconds.
+// It sets the header TimestampHeader to TIMESTAMP and the header Header
+// to the hex-encoded signature.
+type HMACSigner struct {
+	Secret string
+	// Header is the signature header. Defaults to DefaultHMACHeader.
+	Header string
+	// TimestampHeader is the timestamp header.
+	// Defaults to DefaultHMACTimestampHeader.
+	TimestampHeader string
+}
+
+// Sign implements Signer.
+func (s HMACSigner) Sign(req *http.Request, t time.Time) error {
+	bodyHash, err := hashBody(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.URL.RequestURI(),
+		timestamp,
+		bodyHash,
+	}, "\n")
+
+	setHeader(req, defaultString(s.TimestampHeader, DefaultHMACTimestampHeader), timestamp)
+	setHeader(req, defaultString(s.Header, DefaultHMACHeader),
+		hex.EncodeToString(hmacSHA256([]byte(s.Secret), stringToSign)))
+	return nil
+}
+
+// Validate returns a non-nil error if s cannot sign requests.
+func (s HMACSigner) Validate() error {
+	if s.Secret == "" {
+		return errors.New("secret: want non-empty")
+	}
+	return nil
+}
+
+// SigV4Signer is a Signer implementing the AWS Signature Version 4
+// signing process: it sets the headers X-Amz-Date, Authorization and
+// X-Amz-Security-Token if SessionToken is set.
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+}
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// Sign implements Signer.
+func (s SigV4Signer) Sign(req *http.Request, t time.Time) error {
+	bodyHash, err := hashBody(req)
+	if err != nil {
+		return err
+	}
+
+	t = t.UTC()
+	amzDate := t.Format(sigV4TimeFormat)
+	scope := strings.Join([]string{
+		t.Format(sigV4DateFormat), s.Region, s.Service, "aws4_request",
+	}, "/")
+
+	setHeader(req, "X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		setHeader(req, "X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signedHeaders, canonicalHeaders := sigV4CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL),
+		sigV4CanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		bodyHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), t.Format(sigV4DateFormat))
+	for _, part := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	setHeader(req, "Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+// Validate returns a non-nil error if s cannot sign requests.
+func (s SigV4Signer) Validate() error {
+	switch {
+	case s.AccessKeyID == "":
+		return errors.New("accessKeyID: want non-empty")
+	case s.SecretAccessKey == "":
+		return errors.New("secretAccessKey: want non-empty")
+	case s.Region == "":
+		return errors.New("region: want non-empty")
+	case s.Service == "":
+		return errors.New("service: want non-empty")
+	}
+	return nil
+}
+
+// sigV4CanonicalHeaders returns the signed headers of req, i.e. host
+// and the X-Amz-* headers, and their canonical form.
+func sigV4CanonicalHeaders(req *http.Request) (signed, canonical string) {
+	values := map[string]string{"host": requestHost(req)}
+	for key, vals := range req.Header {
+		if lower := strings.ToLower(key); strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = strings.Join(vals, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// sigV4CanonicalURI returns the URI-encoded path of u.
+func sigV4CanonicalURI(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+// sigV4CanonicalQuery returns the query of u sorted by key and value,
+// with spaces encoded as %20.
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+	for key, vals := range query {
+		for _, v := range vals {
+			pairs = append(pairs, sigV4Escape(key)+"="+sigV4Escape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// hashBody returns the hex-encoded SHA-256 of the body of req,
+// read via req.GetBody so that req.Body is left unread.
+func hashBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		if req.Body != nil && req.Body != http.NoBody {
+			return "", errors.New("cannot hash a non-replayable body")
+		}
+		return hashHex(nil), nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// requestHost returns the host req is sent to.
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+func setHeader(req *http.Request, key, value string) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(key, value)
+}
+
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
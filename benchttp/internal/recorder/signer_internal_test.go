@@ -0,0 +1,150 @@
+package recorder
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner_Sign(t *testing.T) {
+	signingTime := time.Unix(1700000000, 0)
+	body := `{"key":"value"}`
+
+	req, _ := http.NewRequest("POST", "http://a.b/orders?page=2", strings.NewReader(body))
+	if err := (HMACSigner{Secret: "secret"}).Sign(req, signingTime); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bodyHash := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/orders?page=2\n1700000000\n" + hex.EncodeToString(bodyHash[:])))
+	expSignature := hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get(DefaultHMACTimestampHeader); got != "1700000000" {
+		t.Errorf("exp timestamp header %q, got %q", "1700000000", got)
+	}
+	if got := req.Header.Get(DefaultHMACHeader); got != expSignature {
+		t.Errorf("exp signature header %q, got %q", expSignature, got)
+	}
+
+	t.Run("use configured headers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://a.b", nil)
+		s := HMACSigner{Secret: "secret", Header: "X-Sig", TimestampHeader: "X-Ts"}
+		if err := s.Sign(req, signingTime); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if req.Header.Get("X-Sig") == "" || req.Header.Get("X-Ts") == "" {
+			t.Errorf("exp configured headers to be set, got %v", req.Header)
+		}
+	})
+}
+
+func TestSigV4Signer_Sign(t *testing.T) {
+	// Test vectors from the AWS Signature Version 4 test suite.
+	signer := SigV4Signer{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+	signingTime := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	testcases := []struct {
+		label        string
+		url          string
+		expSignature string
+	}{
+		{
+			label:        "get-vanilla",
+			url:          "https://example.amazonaws.com/",
+			expSignature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			label:        "get-vanilla-query-order-key-case",
+			url:          "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			expSignature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			if err := signer.Sign(req, signingTime); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			exp := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tc.expSignature
+			if got := req.Header.Get("Authorization"); got != exp {
+				t.Errorf("unexpected Authorization:\nexp %s\ngot %s", exp, got)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("exp X-Amz-Date %q, got %q", "20150830T123600Z", got)
+			}
+		})
+	}
+}
+
+func TestRecorder_Record_signer(t *testing.T) {
+	t.Run("sign each request", func(t *testing.T) {
+		var signatures []string
+		r := withHandlerTransport(New(Config{
+			Requests:       3,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Signer:         HMACSigner{Secret: "secret"},
+		}), http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			signatures = append(signatures, req.Header.Get(DefaultHMACHeader))
+		}))
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", validURI))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, rec := range records {
+			if rec.SignTime <= 0 {
+				t.Errorf("records[%d]: exp SignTime > 0, got %v", i, rec.SignTime)
+			}
+		}
+		// The ping request is not signed.
+		for i, sig := range signatures[1:] {
+			if sig == "" {
+				t.Errorf("request %d: exp signature header", i)
+			}
+		}
+	})
+
+	t.Run("record signing errors", func(t *testing.T) {
+		r := withNoopTransport(New(Config{
+			Requests:       1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Signer:         failingSigner{},
+		}))
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", validURI))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		exp := "signing error: no key"
+		if len(records) != 1 || records[0].Error != exp {
+			t.Errorf("exp a record with error %q, got %+v", exp, records)
+		}
+	})
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(*http.Request, time.Time) error {
+	return errors.New("no key")
+}
@@ -0,0 +1,71 @@
+package recorder
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/benchttp/engine/internal/dispatcher"
+)
+
+// scaleInterval is the interval at which the level of load
+// is updated during a staged run.
+const scaleInterval = 100 * time.Millisecond
+
+// Stage is a step of a load profile. During a Stage, the level of load
+// (concurrency, or rate in open model) ramps linearly from the target
+// of the previous Stage to Target over Duration.
+// A Stage whose Target equals the previous one holds the level of load.
+type Stage struct {
+	Duration time.Duration
+	Target   int
+}
+
+// stagesDuration returns the total duration of stages.
+func stagesDuration(stages []Stage) time.Duration {
+	var total time.Duration
+	for _, s := range stages {
+		total += s.Duration
+	}
+	return total
+}
+
+// levelAt returns the level of load at the given elapsed time
+// according to stages, ramping from base for the first stage.
+// After the last stage, it returns the last stage's target.
+func levelAt(stages []Stage, base int, elapsed time.Duration) int {
+	from := base
+	for _, s := range stages {
+		if elapsed < s.Duration {
+			progress := float64(elapsed) / float64(s.Duration)
+			return from + int(math.Round(float64(s.Target-from)*progress))
+		}
+		elapsed -= s.Duration
+		from = s.Target
+	}
+	return from
+}
+
+// scale updates the level of load of d every scaleInterval according
+// to r.config.Stages until ctx is done.
+func (r *Recorder) scale(ctx context.Context, d dispatcher.Dispatcher) {
+	ticker := time.NewTicker(scaleInterval)
+	defer ticker.Stop()
+	for {
+		d.Scale(levelAt(r.config.Stages, r.baseLevel(), time.Since(r.start)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// baseLevel returns the level of load before any scaling:
+// Config.Rate in open model, else Config.Concurrency.
+func (r *Recorder) baseLevel() int {
+	if r.config.Rate > 0 {
+		return r.config.Rate
+	}
+	return r.config.Concurrency
+}
@@ -0,0 +1,72 @@
+package recorder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLevelAt(t *testing.T) {
+	stages := []Stage{
+		{Duration: 10 * time.Second, Target: 50}, // ramp up from base
+		{Duration: 20 * time.Second, Target: 50}, // hold
+		{Duration: 10 * time.Second, Target: 0},  // ramp down
+	}
+
+	const base = 10
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		exp     int
+	}{
+		{elapsed: 0, exp: 10},
+		{elapsed: 5 * time.Second, exp: 30},
+		{elapsed: 10 * time.Second, exp: 50},
+		{elapsed: 25 * time.Second, exp: 50},
+		{elapsed: 30 * time.Second, exp: 50},
+		{elapsed: 35 * time.Second, exp: 25},
+		{elapsed: 40 * time.Second, exp: 0},
+		{elapsed: 60 * time.Second, exp: 0},
+	} {
+		if got := levelAt(stages, base, tc.elapsed); got != tc.exp {
+			t.Errorf("level at %v: exp %d, got %d", tc.elapsed, tc.exp, got)
+		}
+	}
+}
+
+func TestRecorder_Record_stages(t *testing.T) {
+	t.Run("stop when stages are done", func(t *testing.T) {
+		const stagesTotal = 200 * time.Millisecond
+
+		r := withCallbackTransport(New(Config{
+			Requests:       -1,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			Stages: []Stage{
+				{Duration: stagesTotal / 2, Target: 4},
+				{Duration: stagesTotal / 2, Target: 4},
+			},
+		}), func() { time.Sleep(10 * time.Millisecond) })
+
+		start := time.Now()
+		recs, err := r.Record(context.Background(), validRequest())
+		duration := time.Since(start)
+
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(recs) == 0 {
+			t.Error("no records")
+		}
+
+		if p := r.Progress(); p.Status() != StatusDone {
+			t.Errorf("unexpected status: exp %s, got %s", StatusDone, p.Status())
+		}
+
+		if maxDuration := stagesTotal + 50*time.Millisecond; duration > maxDuration {
+			t.Errorf("unexpected duration: exp < %v, got %v", maxDuration, duration)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package recorder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig configures the TLS connections of the requests,
+// e.g. to trust an internal CA or to authenticate with a client
+// certificate (mTLS). A zero TLSConfig uses the system defaults.
+type TLSConfig struct {
+	// CAFile is the path to a PEM bundle of the certificate authorities
+	// trusted to verify the server certificates, in addition to
+	// the system ones.
+	CAFile string
+	// CertFile and KeyFile are the paths to the PEM client certificate
+	// and its private key, presented to servers requesting one.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables the verification of the server
+	// certificates.
+	InsecureSkipVerify bool
+	// ServerName is the name used to verify the server certificates
+	// and sent via SNI, instead of the host of the request.
+	ServerName string
+	// MinVersion and MaxVersion are the minimum and maximum TLS versions,
+	// e.g. tls.VersionTLS12. Zero values use the crypto/tls defaults.
+	MinVersion uint16
+	MaxVersion uint16
+	// CipherSuites is the list of enabled cipher suites for TLS 1.2
+	// and below, e.g. tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// If nil, the crypto/tls defaults are used.
+	CipherSuites []uint16
+}
+
+// Validate returns a non-nil error if c cannot be loaded,
+// e.g. if its files cannot be read.
+func (c TLSConfig) Validate() error {
+	_, err := c.Load()
+	return err
+}
+
+// Load returns the *tls.Config described by c, reading its files.
+// It returns nil if c is the zero value.
+func (c TLSConfig) Load() (*tls.Config, error) {
+	if c.isZero() {
+		return nil, nil
+	}
+
+	if c.MaxVersion != 0 && c.MinVersion > c.MaxVersion {
+		return nil, fmt.Errorf(
+			"minVersion (%s): want <= maxVersion (%s)",
+			tlsVersionName(c.MinVersion), tlsVersionName(c.MaxVersion),
+		)
+	}
+
+	cfg := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // explicitly configured
+		ServerName:         c.ServerName,
+		MinVersion:         c.MinVersion,
+		MaxVersion:         c.MaxVersion,
+		CipherSuites:       c.CipherSuites,
+	}
+
+	if c.CAFile != "" {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("caFile: %s", err)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("certFile, keyFile: want both or none")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("certFile, keyFile: %s", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (c TLSConfig) isZero() bool {
+	return c.CAFile == "" && c.CertFile == "" && c.KeyFile == "" &&
+		!c.InsecureSkipVerify && c.ServerName == "" &&
+		c.MinVersion == 0 && c.MaxVersion == 0 && c.CipherSuites == nil
+}
+
+// loadCertPool returns the system cert pool with the certificates
+// of the PEM file at path appended.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	return pool, nil
+}
+
+// newClientTLSConfig returns a copy of base, or a new *tls.Config if
+// base is nil, with its own session cache so that the connections
+// opened by a same transport can resume their TLS sessions.
+func newClientTLSConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	cfg.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	return cfg
+}
+
+// tlsVersionName returns the name of the TLS version v,
+// e.g. "TLS 1.3", or its hexadecimal value if unknown.
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", v)
+	}
+}
@@ -0,0 +1,253 @@
+package recorder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSConfig_Load(t *testing.T) {
+	pki := newTestPKI(t)
+
+	t.Run("return nil for zero value", func(t *testing.T) {
+		cfg, err := TLSConfig{}.Load()
+		if cfg != nil || err != nil {
+			t.Errorf("exp nil, nil, got %v, %v", cfg, err)
+		}
+	})
+
+	t.Run("load files", func(t *testing.T) {
+		cfg, err := TLSConfig{
+			CAFile:   pki.caFile,
+			CertFile: pki.clientCertFile,
+			KeyFile:  pki.clientKeyFile,
+		}.Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.RootCAs == nil || len(cfg.Certificates) != 1 {
+			t.Errorf("exp root CAs and a client certificate, got %+v", cfg)
+		}
+	})
+
+	t.Run("return errors for invalid configs", func(t *testing.T) {
+		for _, c := range []TLSConfig{
+			{CAFile: "does-not-exist.pem"},
+			{CAFile: pki.clientKeyFile},
+			{CertFile: pki.clientCertFile},
+			{CertFile: pki.clientCertFile, KeyFile: pki.caFile},
+			{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS12},
+		} {
+			if _, err := c.Load(); err == nil {
+				t.Errorf("exp error for %+v, got nil", c)
+			}
+		}
+	})
+}
+
+func TestRecorder_Record_tls(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := pki.newMTLSServer(t)
+
+	newRecorder := func(cfg TLSConfig, policy ConnectionPolicy) *Recorder {
+		return New(Config{
+			Requests:       3,
+			Concurrency:    1,
+			RequestTimeout: 1 * time.Second,
+			GlobalTimeout:  3 * time.Second,
+			TLS:            cfg,
+			VirtualUsers:   VirtualUsers{Connection: policy},
+		})
+	}
+
+	mtls := TLSConfig{
+		CAFile:   pki.caFile,
+		CertFile: pki.clientCertFile,
+		KeyFile:  pki.clientKeyFile,
+	}
+
+	t.Run("record TLS handshakes", func(t *testing.T) {
+		r := newRecorder(mtls, ConnectionPerRequest)
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, rec := range records {
+			if rec.Error != "" {
+				t.Fatalf("records[%d]: unexpected error: %s", i, rec.Error)
+			}
+			if rec.TLSVersion != "TLS 1.3" || rec.TLSCipherSuite == "" {
+				t.Errorf("records[%d]: exp TLS 1.3 handshake, got %q %q", i, rec.TLSVersion, rec.TLSCipherSuite)
+			}
+		}
+		// The first handshake of the virtual user is a full one.
+		if records[0].TLSResumed || !records[2].TLSResumed {
+			t.Errorf("exp resumed sessions after the first request, got %+v", records)
+		}
+	})
+
+	t.Run("apply versions and cipher suites", func(t *testing.T) {
+		cfg := mtls
+		cfg.MaxVersion = tls.VersionTLS12
+		cfg.CipherSuites = []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+		r := newRecorder(cfg, ConnectionKeepAlive)
+
+		records, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		first := records[0]
+		if first.TLSVersion != "TLS 1.2" || first.TLSCipherSuite != "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256" {
+			t.Errorf("exp TLS 1.2 with configured cipher suite, got %q %q", first.TLSVersion, first.TLSCipherSuite)
+		}
+		// The connection is reused without a new handshake.
+		if last := records[len(records)-1]; last.TLSVersion != "" {
+			t.Errorf("exp no handshake for a reused connection, got %q", last.TLSVersion)
+		}
+	})
+
+	t.Run("return ErrConnection without client certificate", func(t *testing.T) {
+		r := newRecorder(TLSConfig{CAFile: pki.caFile}, ConnectionKeepAlive)
+
+		_, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL))
+		if !errors.Is(err, ErrConnection) {
+			t.Errorf("exp ErrConnection, got %v", err)
+		}
+	})
+
+	t.Run("return ErrConnection for invalid config", func(t *testing.T) {
+		r := newRecorder(TLSConfig{CAFile: "does-not-exist.pem"}, ConnectionKeepAlive)
+
+		_, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL))
+		if !errors.Is(err, ErrConnection) {
+			t.Errorf("exp ErrConnection, got %v", err)
+		}
+	})
+}
+
+// helpers
+
+// testPKI is a certificate authority and the certificates it issued,
+// written as PEM files.
+type testPKI struct {
+	ca         *x509.Certificate
+	caKey      *ecdsa.PrivateKey
+	serverCert tls.Certificate
+
+	caFile         string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	pki := &testPKI{}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "benchttp test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	pki.caKey = mustGenerateKey(t)
+	caDER := mustCreateCertificate(t, caTemplate, caTemplate, pki.caKey, pki.caKey)
+	pki.ca, _ = x509.ParseCertificate(caDER)
+	pki.caFile = writePEM(t, dir, "ca.pem", "CERTIFICATE", caDER)
+
+	serverKey := mustGenerateKey(t)
+	serverDER := mustCreateCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, pki.ca, serverKey, pki.caKey)
+	pki.serverCert = tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+
+	clientKey := mustGenerateKey(t)
+	clientDER := mustCreateCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "benchttp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, pki.ca, clientKey, pki.caKey)
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki.clientCertFile = writePEM(t, dir, "client.pem", "CERTIFICATE", clientDER)
+	pki.clientKeyFile = writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", clientKeyDER)
+
+	return pki
+}
+
+// newMTLSServer returns a started TLS server requiring
+// a client certificate issued by pki.
+func (pki *testPKI) newMTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(pki.ca)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustCreateCertificate(t *testing.T, template, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	b := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
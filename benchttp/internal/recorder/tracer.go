@@ -1,9 +1,11 @@
 package recorder
 
 import (
+	"context"
 	"crypto/tls"
 	"net/http"
 	"net/http/httptrace"
+	"net/textproto"
 	"sync"
 	"time"
 )
@@ -21,14 +23,27 @@ type tracer struct {
 
 	start     time.Time
 	events    []Event
+	tls       *tls.ConnectionState
+	conn      httptrace.GotConnInfo
 	transport http.RoundTripper
 }
 
+type tracerKey struct{}
+
+// tracerFrom returns the tracer of the request of ctx, if any.
+func tracerFrom(ctx context.Context) *tracer {
+	t, _ := ctx.Value(tracerKey{}).(*tracer)
+	return t
+}
+
 // RoundTrip implements http.RoundTripper. It attaches the client trace
 // to the request context and calls t.transport.RoundTrip with a client
-// trace attached to the request context.
+// trace attached to the request context. The events are relative
+// to the start of RoundTrip.
 func (t *tracer) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.start = time.Now()
 	ctx := httptrace.WithClientTrace(r.Context(), t.trace())
+	ctx = context.WithValue(ctx, tracerKey{}, t)
 	return t.transport.RoundTrip(r.WithContext(ctx))
 }
 
@@ -39,32 +54,60 @@ func (t *tracer) RoundTrip(r *http.Request) (*http.Response, error) {
 // method. If the Tracer's Transport does not have a CloseIdleConnections
 // method then this method does nothing.
 func (t *tracer) CloseIdleConnections() {
-	type closeIdler interface{ CloseIdleConnections() }
-	if tr, ok := t.transport.(closeIdler); ok {
-		tr.CloseIdleConnections()
-	}
+	closeIdleConnections(t.transport)
 }
 
 // trace returns a http.ClientTrace that timestamps and records the events
-// of an outgoing HTTP request.
+// of an outgoing HTTP request, one for each hook.
 func (t *tracer) trace() *httptrace.ClientTrace {
 	return &httptrace.ClientTrace{
 		GetConn: func(string) {
-			t.start = time.Now()
+			t.addEvent("GetConn")
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.addEvent("DNSStart")
 		},
 		DNSDone: func(httptrace.DNSDoneInfo) {
 			t.addEvent("DNSDone")
 		},
+		ConnectStart: func(string, string) {
+			t.addEvent("ConnectStart")
+		},
 		ConnectDone: func(string, string, error) {
 			t.addEvent("ConnectDone")
 		},
-		TLSHandshakeDone: func(tls.ConnectionState, error) {
+		TLSHandshakeStart: func() {
+			t.addEvent("TLSHandshakeStart")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
 			t.addEvent("TLSHandshakeDone")
+			if err == nil {
+				t.setTLS(state)
+			}
 		},
 
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.addEvent("GotConn")
+			t.setConn(info)
+			// http2.Transport does not call TLSHandshakeDone:
+			// take the state of a new connection from the connection.
+			if conn, ok := info.Conn.(*tls.Conn); ok && !info.Reused {
+				t.setTLSIfUnset(conn.ConnectionState())
+			}
+		},
 		WroteHeaders: func() {
 			t.addEvent("WroteHeaders")
 		},
+		Got100Continue: func() {
+			t.addEvent("Got100Continue")
+		},
+		Got1xxResponse: func(int, textproto.MIMEHeader) error {
+			t.addEvent("Got1xxResponse")
+			return nil
+		},
+		Wait100Continue: func() {
+			t.addEvent("Wait100Continue")
+		},
 		WroteRequest: func(httptrace.WroteRequestInfo) {
 			t.addEvent("WroteRequest")
 		},
@@ -84,16 +127,41 @@ func (t *tracer) addEvent(name string) {
 	t.events = append(t.events, Event{Name: name, Time: time.Since(t.start)})
 }
 
+// setConn stores the information about the connection of the request.
+func (t *tracer) setConn(info httptrace.GotConnInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conn = info
+}
+
+// setTLS stores the state of the TLS handshake of the request.
+func (t *tracer) setTLS(state tls.ConnectionState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tls = &state
+}
+
+// setTLSIfUnset stores the state of the TLS handshake of the request
+// if it is not already stored.
+func (t *tracer) setTLSIfUnset(state tls.ConnectionState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tls == nil {
+		t.tls = &state
+	}
+}
+
 // addEventBodyRead adds event BodyRead to the tracer's events slice.
 func (t *tracer) addEventBodyRead() {
 	t.addEvent("BodyRead")
 }
 
-// newTracer returns an initialized tracer.
-func newTracer() *tracer {
+// newTracer returns an initialized tracer sending requests
+// with transport.
+func newTracer(transport http.RoundTripper) *tracer {
 	return &tracer{
 		events:    make([]Event, 0, 20),
-		transport: http.DefaultTransport,
+		transport: transport,
 	}
 }
 
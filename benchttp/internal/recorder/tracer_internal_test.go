@@ -2,27 +2,39 @@ package recorder
 
 import (
 	"crypto/tls"
+	"net/http"
 	"net/http/httptrace"
 	"testing"
+	"time"
 )
 
 func TestTracer(t *testing.T) {
 	t.Run("append events on trace hooks", func(t *testing.T) {
-		tracer := newTracer()
+		tracer := newTracer(http.DefaultTransport)
 		trace := tracer.trace()
+		tracer.start = time.Now() // set by RoundTrip
 
 		trace.GetConn("")
+		trace.DNSStart(httptrace.DNSStartInfo{})
 		trace.DNSDone(httptrace.DNSDoneInfo{})
+		trace.ConnectStart("", "")
 		trace.ConnectDone("", "", nil)
+		trace.TLSHandshakeStart()
 		trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+		trace.GotConn(httptrace.GotConnInfo{})
 		trace.WroteHeaders()
+		trace.Wait100Continue()
+		trace.Got100Continue()
+		trace.Got1xxResponse(http.StatusContinue, nil) //nolint:errcheck
 		trace.WroteRequest(httptrace.WroteRequestInfo{})
 		trace.GotFirstResponseByte()
 		trace.PutIdleConn(nil)
 
 		expEventNames := []string{
-			"DNSDone", "ConnectDone", "TLSHandshakeDone", "WroteHeaders",
-			"WroteRequest", "GotFirstResponseByte", "PutIdleConn",
+			"GetConn", "DNSStart", "DNSDone", "ConnectStart", "ConnectDone",
+			"TLSHandshakeStart", "TLSHandshakeDone", "GotConn", "WroteHeaders",
+			"Wait100Continue", "Got100Continue", "Got1xxResponse", "WroteRequest",
+			"GotFirstResponseByte", "PutIdleConn",
 		}
 		gotEvents := tracer.events
 
@@ -0,0 +1,141 @@
+package recorder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+	"time"
+)
+
+// ConnectionPolicy determines how the connections of a virtual user
+// are reused between its requests.
+type ConnectionPolicy string
+
+const (
+	// ConnectionKeepAlive keeps the connections of a virtual user alive
+	// and reuses them for its next requests, as a returning user would.
+	ConnectionKeepAlive ConnectionPolicy = "keepAlive"
+	// ConnectionNoKeepAlive disables keep-alive: each request is sent
+	// with the header Connection: close over HTTP/1.1, and is the last
+	// request of its connection over HTTP/2.
+	ConnectionNoKeepAlive ConnectionPolicy = "noKeepAlive"
+	// ConnectionPerRequest closes the connections of a virtual user
+	// after each of its requests, so that each request is sent
+	// on a new connection without changing its headers.
+	ConnectionPerRequest ConnectionPolicy = "perRequest"
+)
+
+// VirtualUsers determines the state owned by each concurrent worker,
+// or virtual user, and kept across its iterations: its connections
+// and optionally its cookies.
+type VirtualUsers struct {
+	// Cookies enables a cookie jar for each virtual user, storing
+	// the cookies it receives and sending them with its next requests.
+	Cookies bool
+	// Connection determines how the connections of a virtual user
+	// are reused. Defaults to ConnectionKeepAlive.
+	Connection ConnectionPolicy
+}
+
+// Validate returns a non-nil error if v is not a valid configuration.
+// A zero VirtualUsers is valid.
+func (v VirtualUsers) Validate() error {
+	switch v.Connection {
+	case "", ConnectionKeepAlive, ConnectionNoKeepAlive, ConnectionPerRequest:
+		return nil
+	default:
+		return fmt.Errorf(
+			"connection: unknown policy %q: want %q, %q or %q",
+			v.Connection, ConnectionKeepAlive, ConnectionNoKeepAlive, ConnectionPerRequest,
+		)
+	}
+}
+
+// virtualUser is the state of a worker kept across its iterations.
+// It sends a single request at a time.
+type virtualUser struct {
+	transport http.RoundTripper
+	jar       http.CookieJar
+	policy    ConnectionPolicy
+}
+
+// client returns a http.Client sending requests with the transport
+// and cookies of u, and the tracer recording the events of its request.
+func (u *virtualUser) client(timeout time.Duration) (*http.Client, *tracer) {
+	t := newTracer(u.transport)
+	client := newClient(t, timeout)
+	client.Jar = u.jar
+	return client, t
+}
+
+// prepare is called before each request of u is sent.
+func (u *virtualUser) prepare(req *http.Request) {
+	if u.policy == ConnectionNoKeepAlive {
+		req.Close = true
+	}
+}
+
+// done is called after each request of u, once its response is read.
+func (u *virtualUser) done() {
+	if u.policy == ConnectionPerRequest {
+		closeIdleConnections(u.transport)
+	}
+}
+
+// userPool holds the virtual user of each worker index, created
+// on first use. Its zero value is not ready to use: see newUserPool.
+type userPool struct {
+	mu           sync.Mutex
+	users        []*virtualUser
+	config       VirtualUsers
+	newTransport func() http.RoundTripper
+}
+
+func newUserPool(cfg VirtualUsers, newTransport func() http.RoundTripper) *userPool {
+	return &userPool{config: cfg, newTransport: newTransport}
+}
+
+// get returns the virtual user of the worker index i,
+// creating it if needed.
+func (p *userPool) get(i int) *virtualUser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.users) <= i {
+		p.users = append(p.users, nil)
+	}
+	if p.users[i] == nil {
+		p.users[i] = p.newUser()
+	}
+	return p.users[i]
+}
+
+func (p *userPool) newUser() *virtualUser {
+	u := &virtualUser{
+		transport: p.newTransport(),
+		policy:    p.config.Connection,
+	}
+	if p.config.Cookies {
+		// err is always nil with nil options
+		u.jar, _ = cookiejar.New(nil)
+	}
+	return u
+}
+
+// close closes the idle connections of all virtual users.
+func (p *userPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, u := range p.users {
+		if u != nil {
+			closeIdleConnections(u.transport)
+		}
+	}
+}
+
+func closeIdleConnections(t http.RoundTripper) {
+	type closeIdler interface{ CloseIdleConnections() }
+	if tr, ok := t.(closeIdler); ok {
+		tr.CloseIdleConnections()
+	}
+}
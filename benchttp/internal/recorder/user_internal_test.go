@@ -0,0 +1,163 @@
+package recorder
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVirtualUsers_Validate(t *testing.T) {
+	for _, v := range []VirtualUsers{
+		{},
+		{Cookies: true, Connection: ConnectionKeepAlive},
+		{Connection: ConnectionNoKeepAlive},
+		{Connection: ConnectionPerRequest},
+	} {
+		if err := v.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", v, err)
+		}
+	}
+
+	if err := (VirtualUsers{Connection: "pooled"}).Validate(); err == nil {
+		t.Error("exp error for unknown connection policy, got nil")
+	}
+}
+
+func TestRecorder_Record_virtualUsers(t *testing.T) {
+	t.Run("apply connection policy", func(t *testing.T) {
+		testcases := []struct {
+			policy         ConnectionPolicy
+			expConnections int
+			expClose       int
+			expReused      int
+		}{
+			// The ping request is sent on its own connection.
+			{policy: "", expConnections: 2, expReused: 4},
+			{policy: ConnectionKeepAlive, expConnections: 2, expReused: 4},
+			{policy: ConnectionNoKeepAlive, expConnections: 6, expClose: 5},
+			{policy: ConnectionPerRequest, expConnections: 6},
+		}
+
+		for _, tc := range testcases {
+			t.Run(string(tc.policy), func(t *testing.T) {
+				var (
+					mu     sync.Mutex
+					closes int
+				)
+				srv := newConnServer(t, func(w http.ResponseWriter, req *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					if req.Close {
+						closes++
+					}
+				})
+
+				r := New(Config{
+					Requests:       5,
+					Concurrency:    1,
+					RequestTimeout: 1 * time.Second,
+					GlobalTimeout:  3 * time.Second,
+					VirtualUsers:   VirtualUsers{Connection: tc.policy},
+				})
+
+				records, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				reused := 0
+				for _, rec := range records {
+					if rec.ConnReused {
+						reused++
+						if !rec.ConnWasIdle || rec.ConnIdleTime <= 0 {
+							t.Errorf("exp reused connection to be idle, got %+v", rec)
+						}
+					}
+				}
+				if reused != tc.expReused {
+					t.Errorf("exp %d requests on reused connections, got %d", tc.expReused, reused)
+				}
+				if got := srv.connections(); got != tc.expConnections {
+					t.Errorf("exp %d connections, got %d", tc.expConnections, got)
+				}
+				if closes != tc.expClose {
+					t.Errorf("exp %d requests with Connection: close, got %d", tc.expClose, closes)
+				}
+			})
+		}
+	})
+
+	t.Run("keep cookies per virtual user", func(t *testing.T) {
+		for _, cookies := range []bool{false, true} {
+			var (
+				mu          sync.Mutex
+				withCookies int
+			)
+			srv := newConnServer(t, func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if _, err := req.Cookie("session"); err == nil {
+					withCookies++
+					return
+				}
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			})
+
+			r := New(Config{
+				Requests:       6,
+				Concurrency:    2,
+				RequestTimeout: 1 * time.Second,
+				GlobalTimeout:  3 * time.Second,
+				VirtualUsers:   VirtualUsers{Cookies: cookies},
+			})
+
+			if _, err := r.Record(context.Background(), mustNewRequest("GET", srv.URL)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Each virtual user receives the cookie on its first request.
+			if cookies && (withCookies < 4 || withCookies > 5) {
+				t.Errorf("exp 4 or 5 requests with cookies, got %d", withCookies)
+			}
+			if !cookies && withCookies != 0 {
+				t.Errorf("exp no request with cookies, got %d", withCookies)
+			}
+		}
+	})
+}
+
+// helpers
+
+type connServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	conns int
+}
+
+func (s *connServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// newConnServer returns a started server serving handler
+// and counting the connections it accepts.
+func newConnServer(t *testing.T, handler http.HandlerFunc) *connServer {
+	t.Helper()
+	s := &connServer{}
+	s.Server = httptest.NewUnstartedServer(handler)
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
@@ -1,6 +1,7 @@
 package reflectpath
 
 import (
+	"reflect"
 	"regexp"
 )
 
@@ -19,6 +20,35 @@ type Resolver struct {
 	// paths that are accessible by the Resolver.
 	// If not set, there is no restriction.
 	AllowedPatterns []string
+	// InlineKeyPattern is a regexp pattern the names resolved as keys
+	// of inline maps must match. If not set, inline maps are not
+	// resolved.
+	InlineKeyPattern string
+}
+
+// isInlineKey returns true if name can be resolved as a key
+// of an inline map.
+func (r Resolver) isInlineKey(name string) bool {
+	if r.InlineKeyPattern == "" {
+		return false
+	}
+	return regexp.MustCompile(r.InlineKeyPattern).MatchString(name)
+}
+
+// inlineMapField returns the first map field of the struct type host
+// tagged `reflectpath:"inline"`, if any. The keys of an inline map
+// matching Resolver.InlineKeyPattern are resolved as properties of
+// the struct, after its fields and methods, e.g. "Stats.P99" for a map
+// key "P99" of the inline field of Stats. As for any map, a missing key
+// resolves to the zero value of its elements.
+func inlineMapField(host reflect.Type) (reflect.StructField, bool) {
+	for i := 0; i < host.NumField(); i++ {
+		field := host.Field(i)
+		if field.Tag.Get("reflectpath") == "inline" && field.Type.Kind() == reflect.Map {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
 }
 
 func (r Resolver) safeMatchFunc(pathname string) func(string) bool {
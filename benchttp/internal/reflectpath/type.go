@@ -36,7 +36,17 @@ func (r Resolver) resolvePropertyType(host reflect.Type, name string) reflect.Ty
 	kind := host.Kind()
 	switch kind {
 	case reflect.Struct:
-		return propertyTypeByNameFunc(host, match)
+		if name == Wildcard {
+			// Wildcards only match the elements of slices and maps.
+			return nil
+		}
+		if typ := propertyTypeByNameFunc(host, match); typ != nil {
+			return typ
+		}
+		if inline, ok := inlineMapField(host); ok && r.isInlineKey(name) {
+			return inline.Type.Elem()
+		}
+		return nil
 	case reflect.Map, reflect.Slice:
 		return host.Elem()
 	}
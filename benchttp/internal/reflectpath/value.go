@@ -3,6 +3,7 @@ package reflectpath
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -37,7 +38,13 @@ func (r Resolver) resolveProperty(host reflect.Value, name string) reflect.Value
 	kind := host.Kind()
 	switch kind {
 	case reflect.Struct:
-		return propertyByNameFunc(host, match)
+		if v := propertyByNameFunc(host, match); v.IsValid() {
+			return v
+		}
+		if inline, ok := inlineMapField(host.Type()); ok && r.isInlineKey(name) {
+			return mapIndexFunc(host.FieldByIndex(inline.Index), match)
+		}
+		return reflect.Value{}
 	case reflect.Map:
 		return mapIndexFunc(host, match)
 	case reflect.Slice:
@@ -99,8 +106,70 @@ func sliceIndex(host reflect.Value, istr string) reflect.Value {
 	return zeroValueElemOf(host)
 }
 
-// zeroValueOfElem returns the zero value of the element type of host.
+// zeroValueElemOf returns the zero value of the element type of host.
 // It panics if host is not a slice or map.
 func zeroValueElemOf(host reflect.Value) reflect.Value {
-	return reflect.Zero(host.Elem().Type())
+	return reflect.Zero(host.Type().Elem())
+}
+
+// Wildcard is a path segment matching every element of a slice or map.
+const Wildcard = "*"
+
+// ResolveValues resolves pathRepr starting from host like ResolveValue,
+// expanding each Wildcard segment to every element of the slice or map
+// at that point of the path. It returns the concrete paths of the
+// matching values, in which wildcards are replaced with slice indexes
+// or sorted map keys, and the values themselves.
+func (r Resolver) ResolveValues(host interface{}, pathRepr string) ([]string, []reflect.Value) {
+	if !r.isPathAllowed(pathRepr) {
+		return nil, nil
+	}
+	var (
+		paths  []string
+		values []reflect.Value
+	)
+	var resolve func(current reflect.Value, resolved, pathStack []string)
+	resolve = func(current reflect.Value, resolved, pathStack []string) {
+		if len(pathStack) == 0 {
+			paths = append(paths, strings.Join(resolved, "."))
+			values = append(values, current)
+			return
+		}
+		name := pathStack[0]
+		if name != Wildcard {
+			next := r.resolveProperty(current, name)
+			resolve(next, append(resolved, name), pathStack[1:])
+			return
+		}
+		for _, key := range elemKeys(current) {
+			next := r.resolveProperty(current, key)
+			resolve(next, append(resolved[:len(resolved):len(resolved)], key), pathStack[1:])
+		}
+	}
+	resolve(reflect.ValueOf(host), nil, strings.Split(pathRepr, "."))
+	return paths, values
+}
+
+// elemKeys returns the indexes of the elements of the slice host,
+// or the sorted keys of the map host, as path segments.
+// It returns nil if host is neither a slice nor a map.
+func elemKeys(host reflect.Value) []string {
+	switch host.Kind() {
+	case reflect.Slice:
+		keys := make([]string, host.Len())
+		for i := range keys {
+			keys[i] = strconv.Itoa(i)
+		}
+		return keys
+	case reflect.Map:
+		keys := make([]string, 0, host.Len())
+		iter := host.MapRange()
+		for iter.Next() {
+			keys = append(keys, fmt.Sprint(iter.Key().Interface()))
+		}
+		sort.Strings(keys)
+		return keys
+	default:
+		return nil
+	}
 }
@@ -0,0 +1,275 @@
+// Package render renders the templates contained in HTTP requests,
+// such as references to the variables of a scenario or random values.
+//
+// Templates use the text/template syntax, with the data being a map
+// of variables: "/orders/{{.orderID}}" renders the variable orderID.
+// Referencing an undefined variable is an error.
+//
+// The following functions are available:
+//
+//	uuid              a random UUID (version 4)
+//	randInt min max   a random integer in [min, max]
+//	now               the current time, formatted as RFC 3339
+//
+// Random values are generated from the seed of the Renderer, so that
+// the sequence of generated values can be reproduced. WithSeed returns
+// a Renderer with its own seed, e.g. one per iteration of a run, so that
+// the values do not depend on the order concurrent renderings run in.
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const delim = "{{"
+
+// Renderer renders templates. It is safe for concurrent use.
+type Renderer struct {
+	funcs     template.FuncMap
+	templates *sync.Map // map[string]*template.Template
+}
+
+// New returns a Renderer generating random values from seed.
+func New(seed int64) *Renderer {
+	return &Renderer{
+		funcs:     newFuncs(seed),
+		templates: &sync.Map{},
+	}
+}
+
+// WithSeed returns a Renderer sharing the parsed templates of r
+// and generating random values from seed.
+func (r *Renderer) WithSeed(seed int64) *Renderer {
+	return &Renderer{
+		funcs:     newFuncs(seed),
+		templates: r.templates,
+	}
+}
+
+// newFuncs returns the template functions, generating
+// random values from seed.
+func newFuncs(seed int64) template.FuncMap {
+	rnd := &lockedRand{seed: seed}
+	return template.FuncMap{
+		"uuid":    rnd.uuid,
+		"randInt": rnd.intRange,
+		"now": func() string {
+			return time.Now().Format(time.RFC3339Nano)
+		},
+	}
+}
+
+// Validate returns a non-nil error if s is not a valid template.
+func (r *Renderer) Validate(s string) error {
+	if !strings.Contains(s, delim) {
+		return nil
+	}
+	_, err := r.template(s)
+	return err
+}
+
+// String renders s as a template with data.
+// It returns s unchanged if it does not contain any template.
+func (r *Renderer) String(s string, data map[string]string) (string, error) {
+	if !strings.Contains(s, delim) {
+		return s, nil
+	}
+
+	t, err := r.template(s)
+	if err != nil {
+		return "", err
+	}
+
+	// The cached templates may have been parsed by another Renderer
+	// sharing them: execute them with the functions of r.
+	t, err = t.Clone()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := t.Funcs(r.funcs).Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// template returns the parsed template of s, parsing it on first use.
+func (r *Renderer) template(s string) (*template.Template, error) {
+	if t, ok := r.templates.Load(s); ok {
+		return t.(*template.Template), nil
+	}
+
+	t, err := template.New("").Option("missingkey=error").Funcs(r.funcs).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	r.templates.Store(s, t)
+	return t, nil
+}
+
+// TemplateBody is implemented by the request bodies that are templates.
+// Renderer.Request only renders the bodies whose IsTemplate method
+// returns true, other bodies are sent untouched, e.g. binary files.
+type TemplateBody interface {
+	IsTemplate() bool
+}
+
+// isTemplateBody returns true if the body of req is a TemplateBody
+// template that can be read again via req.GetBody.
+func isTemplateBody(req *http.Request) bool {
+	body, ok := req.Body.(TemplateBody)
+	return ok && body.IsTemplate() && req.GetBody != nil
+}
+
+// Request returns a clone of req bound to ctx, in which the templates
+// of the URL path and query, the header values and the body are rendered
+// with data. The body is only rendered if it is a TemplateBody template
+// and req.GetBody is set.
+func (r *Renderer) Request(ctx context.Context, req *http.Request, data map[string]string) (*http.Request, error) {
+	clone := req.Clone(ctx)
+
+	if req.URL != nil {
+		u, err := r.renderURL(req.URL, data)
+		if err != nil {
+			return nil, err
+		}
+		clone.URL = u
+	}
+
+	for key, values := range req.Header {
+		rendered := make([]string, len(values))
+		for i, v := range values {
+			s, err := r.String(v, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = s
+		}
+		clone.Header[key] = rendered
+	}
+
+	if isTemplateBody(req) {
+		if err := r.renderBody(clone, req, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// renderURL returns a copy of u with the templates of its path
+// and its query rendered with data. Templates are looked up in
+// the decoded path and query, as their delimiters are escaped
+// in the encoded forms.
+func (r *Renderer) renderURL(u *url.URL, data map[string]string) (*url.URL, error) {
+	rendered := *u
+
+	if strings.Contains(u.Path, delim) {
+		path, err := r.String(u.Path, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Path = path
+		rendered.RawPath = ""
+	}
+
+	if query, err := url.QueryUnescape(u.RawQuery); err == nil && strings.Contains(query, delim) {
+		values, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return nil, err
+		}
+		renderedValues := url.Values{}
+		for key, vals := range values {
+			renderedKey, err := r.String(key, data)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range vals {
+				renderedVal, err := r.String(v, data)
+				if err != nil {
+					return nil, err
+				}
+				renderedValues.Add(renderedKey, renderedVal)
+			}
+		}
+		rendered.RawQuery = renderedValues.Encode()
+	}
+
+	return &rendered, nil
+}
+
+// renderBody sets the body of dst to the body of src rendered with data.
+func (r *Renderer) renderBody(dst, src *http.Request, data map[string]string) error {
+	body, err := src.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := r.String(string(raw), data)
+	if err != nil {
+		return err
+	}
+
+	b := []byte(rendered)
+	dst.Body = io.NopCloser(bytes.NewReader(b))
+	dst.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	dst.ContentLength = int64(len(b))
+	return nil
+}
+
+// lockedRand is a source of random values safe for concurrent use.
+// Its generator is seeded with seed on first use.
+type lockedRand struct {
+	mu   sync.Mutex
+	seed int64
+	rnd  *rand.Rand
+}
+
+// rand returns the generator of l. l.mu must be held.
+func (l *lockedRand) rand() *rand.Rand {
+	if l.rnd == nil {
+		l.rnd = rand.New(rand.NewSource(l.seed)) //nolint:gosec
+	}
+	return l.rnd
+}
+
+// intRange returns a random integer in [min, max].
+func (l *lockedRand) intRange(lo, hi int) (int, error) {
+	if hi < lo {
+		return 0, fmt.Errorf("randInt: max (%d) < min (%d)", hi, lo)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return lo + l.rand().Intn(hi-lo+1), nil
+}
+
+// uuid returns a random UUID (version 4, RFC 4122).
+func (l *lockedRand) uuid() string {
+	var b [16]byte
+	l.mu.Lock()
+	l.rand().Read(b[:]) //nolint:errcheck // never returns an error
+	l.mu.Unlock()
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
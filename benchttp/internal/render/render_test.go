@@ -0,0 +1,199 @@
+package render_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/benchttp/engine/benchttp/internal/render"
+)
+
+func TestString(t *testing.T) {
+	data := map[string]string{"id": "42"}
+
+	for _, tc := range []struct {
+		name   string
+		in     string
+		exp    string
+		expErr bool
+	}{
+		{name: "no template", in: "/orders/{id}", exp: "/orders/{id}"},
+		{name: "render variable", in: "/orders/{{.id}}", exp: "/orders/42"},
+		{name: "error for undefined variable", in: "{{.token}}", expErr: true},
+		{name: "error for invalid template", in: "{{.id", expErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := render.New(0).String(tc.in, data)
+			if gotErr := err != nil; gotErr != tc.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.exp {
+				t.Errorf("exp %q, got %q", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	data := map[string]string{"id": "42", "token": "abc"}
+
+	req := newRequestWithBody(
+		"http://example.com/orders/{{.id}}?ref={{.id}}",
+		[]byte(`{"order":"{{.id}}"}`), true,
+	)
+	req.Header.Set("Authorization", "Bearer {{.token}}")
+
+	got, err := render.New(0).Request(context.Background(), req, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if u, exp := got.URL.String(), "http://example.com/orders/42?ref=42"; u != exp {
+		t.Errorf("url: exp %q, got %q", exp, u)
+	}
+
+	if h, exp := got.Header.Get("Authorization"), "Bearer abc"; h != exp {
+		t.Errorf("header: exp %q, got %q", exp, h)
+	}
+
+	body, _ := io.ReadAll(got.Body)
+	if exp := `{"order":"42"}`; string(body) != exp {
+		t.Errorf("body: exp %q, got %q", exp, body)
+	}
+	if got.ContentLength != int64(len(body)) {
+		t.Errorf("content length: exp %d, got %d", len(body), got.ContentLength)
+	}
+
+	if h := req.Header.Get("Authorization"); h != "Bearer {{.token}}" {
+		t.Errorf("original request was modified: %q", h)
+	}
+
+	t.Run("send non-template bodies untouched", func(t *testing.T) {
+		binary := []byte("\x00{{\x00\xff}}")
+		req := newRequestWithBody("http://example.com", binary, false)
+
+		got, err := render.New(0).Request(context.Background(), req, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		body, _ := got.GetBody()
+		if b, _ := io.ReadAll(body); !bytes.Equal(b, binary) {
+			t.Errorf("body: exp %q, got %q", binary, b)
+		}
+	})
+}
+
+func TestRenderer_funcs(t *testing.T) {
+	t.Run("uuid", func(t *testing.T) {
+		got, err := render.New(0).String("{{uuid}}", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		uuidRgx := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		if !uuidRgx.MatchString(got) {
+			t.Errorf("invalid uuid: %q", got)
+		}
+	})
+
+	t.Run("randInt", func(t *testing.T) {
+		r := render.New(0)
+		for i := 0; i < 100; i++ {
+			got, err := r.String("{{randInt 1 3}}", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n, _ := strconv.Atoi(got); n < 1 || n > 3 {
+				t.Fatalf("exp value in [1, 3], got %q", got)
+			}
+		}
+
+		if _, err := r.String("{{randInt 3 1}}", nil); err == nil {
+			t.Error("exp error for max < min, got nil")
+		}
+	})
+
+	t.Run("now", func(t *testing.T) {
+		got, err := render.New(0).String("{{now}}", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, got); err != nil {
+			t.Errorf("invalid time: %v", err)
+		}
+	})
+
+	t.Run("reproduce values with the same seed", func(t *testing.T) {
+		const tmpl = "{{uuid}} {{randInt 0 1000000}}"
+		generate := func(seed int64) []string {
+			r := render.New(seed)
+			values := make([]string, 10)
+			for i := range values {
+				values[i], _ = r.String(tmpl, nil)
+			}
+			return values
+		}
+
+		a, b, c := generate(42), generate(42), generate(43)
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("exp same values for same seed, got %q and %q", a[i], b[i])
+			}
+		}
+		if a[0] == c[0] {
+			t.Errorf("exp different values for different seeds, got %q twice", a[0])
+		}
+	})
+
+	t.Run("reproduce values of renderers with their own seed", func(t *testing.T) {
+		const tmpl = "{{uuid}} {{randInt 0 1000000}}"
+		r := render.New(0)
+		generate := func(seed int64) string {
+			got, err := r.WithSeed(seed).String(tmpl, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return got
+		}
+
+		a1, b1 := generate(1), generate(2)
+		// Rendering in a different order does not change the values.
+		b2, a2 := generate(2), generate(1)
+		if a1 != a2 || b1 != b2 {
+			t.Errorf("exp same values for same seeds, got %q, %q and %q, %q", a1, a2, b1, b2)
+		}
+		if a1 == b1 {
+			t.Errorf("exp different values for different seeds, got %q twice", a1)
+		}
+	})
+}
+
+// helpers
+
+// templateBody is a render.TemplateBody.
+type templateBody struct {
+	io.ReadCloser
+	template bool
+}
+
+func (b templateBody) IsTemplate() bool {
+	return b.template
+}
+
+// newRequestWithBody returns a POST request to uri with the replayable
+// body b, that is a template if template is true.
+func newRequestWithBody(uri string, b []byte, template bool) *http.Request {
+	req, _ := http.NewRequest("POST", uri, nil)
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.Body = templateBody{ReadCloser: req.Body, template: template}
+	req.ContentLength = int64(len(b))
+	return req
+}
@@ -41,18 +41,42 @@ func Run(agg metrics.Aggregate, cases []Case) SuiteResult {
 	}
 }
 
+// runTestCase runs c against agg. If c.Field contains wildcards,
+// c passes if every matching metric passes, and the result reports
+// the first failing metric, or the first metric if all pass.
+// It fails if no metric matches c.Field.
 func runTestCase(agg metrics.Aggregate, c Case) CaseResult {
-	gotMetric := agg.MetricOf(c.Field)
+	gotMetrics := agg.MetricsOf(c.Field)
 	tarMetric := metrics.Metric{Field: c.Field, Value: c.Target}
-	comparisonResult := gotMetric.Compare(tarMetric)
-
-	return CaseResult{
-		Input: c,
-		Pass:  c.Predicate.match(comparisonResult),
-		Got:   gotMetric.Value,
-		Summary: fmt.Sprintf(
-			"want %s %s %v, got %v",
-			c.Field, c.Predicate.symbol(), c.Target, gotMetric.Value,
-		),
+
+	result := CaseResult{Input: c}
+	for i, gotMetric := range gotMetrics {
+		pass := c.Predicate.match(gotMetric.Compare(tarMetric))
+		if i == 0 || (!pass && result.Pass) {
+			result.Pass = pass
+			result.Got = gotMetric.Value
+			result.Summary = summary(c, gotMetric)
+		}
+	}
+	if len(gotMetrics) == 0 {
+		result.Summary = fmt.Sprintf(
+			"want %s %s %v, got no matching metric",
+			c.Field, c.Predicate.symbol(), c.Target,
+		)
+	}
+	return result
+}
+
+// summary returns the summary of the result of c for the metric got.
+func summary(c Case, got metrics.Metric) string {
+	if got.Field != c.Field {
+		return fmt.Sprintf(
+			"want %s %s %v, got %v (%s)",
+			c.Field, c.Predicate.symbol(), c.Target, got.Value, got.Field,
+		)
 	}
+	return fmt.Sprintf(
+		"want %s %s %v, got %v",
+		c.Field, c.Predicate.symbol(), c.Target, got.Value,
+	)
 }
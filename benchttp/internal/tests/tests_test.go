@@ -64,6 +64,74 @@ func TestRun(t *testing.T) {
 				{Pass: true, Got: ms(200), Summary: "want ResponseTimes.Mean > 80ms, got 200ms"},
 			},
 		},
+		{
+			label: "pass if all wildcard metrics pass",
+			inputAgg: metrics.Aggregate{
+				TimeSeries: metrics.TimeSeries{
+					{Throughput: 120, ResponseTimes: timestats.TimeStats{Max: ms(100)}},
+					{Throughput: 80, ResponseTimes: timestats.TimeStats{Max: ms(300)}},
+					{Throughput: 100, ResponseTimes: timestats.TimeStats{Max: ms(400)}},
+				},
+			},
+			inputCases: []tests.Case{
+				{
+					Name:      "max response time below 200ms in every window (fail)",
+					Predicate: tests.LT,
+					Field:     "TimeSeries.*.ResponseTimes.Max",
+					Target:    ms(200),
+				},
+				{
+					Name:      "throughput above 50 in every window (pass)",
+					Predicate: tests.GT,
+					Field:     "TimeSeries.*.Throughput",
+					Target:    50.0,
+				},
+			},
+			expGlobalPass: false,
+			expCaseResults: []tests.CaseResult{
+				{
+					Pass:    false,
+					Got:     ms(300),
+					Summary: "want TimeSeries.*.ResponseTimes.Max < 200ms, got 300ms (TimeSeries.1.ResponseTimes.Max)",
+				},
+				{
+					Pass:    true,
+					Got:     120.0,
+					Summary: "want TimeSeries.*.Throughput > 50, got 120 (TimeSeries.0.Throughput)",
+				},
+			},
+		},
+		{
+			label:    "fail if no wildcard metric matches",
+			inputAgg: metrics.Aggregate{},
+			inputCases: []tests.Case{
+				{
+					Name:      "max response time below 200ms in every window (fail)",
+					Predicate: tests.LT,
+					Field:     "TimeSeries.*.ResponseTimes.Max",
+					Target:    ms(200),
+				},
+				{
+					Name:      "mean response time below 200ms for every request (fail)",
+					Predicate: tests.LT,
+					Field:     "Requests.*.ResponseTimes.Mean",
+					Target:    ms(200),
+				},
+			},
+			expGlobalPass: false,
+			expCaseResults: []tests.CaseResult{
+				{
+					Pass:    false,
+					Got:     nil,
+					Summary: "want TimeSeries.*.ResponseTimes.Max < 200ms, got no matching metric",
+				},
+				{
+					Pass:    false,
+					Got:     nil,
+					Summary: "want Requests.*.ResponseTimes.Mean < 200ms, got no matching metric",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
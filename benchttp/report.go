@@ -19,12 +19,22 @@ type Metadata struct {
 	Runner        Runner
 	FinishedAt    time.Time
 	TotalDuration time.Duration
+	// WarmupCount is the number of requests sent during the warm-up,
+	// whose results are excluded from the metrics.
+	WarmupCount int
+	// Abort describes the abort condition that stopped the run early,
+	// or is nil if the run was not aborted.
+	Abort *RunAbort
+	// Seed is the seed of the random values generated by the templates
+	// of the requests. It can be set in Runner.Seed to reproduce them.
+	Seed int64
 }
 
 // newReport returns an initialized *Report.
 func newReport(
 	r Runner,
 	d time.Duration,
+	warmupCount int,
 	m metrics.Aggregate,
 	t tests.SuiteResult,
 ) *Report {
@@ -35,6 +45,7 @@ func newReport(
 			Runner:        r,
 			FinishedAt:    time.Now(), // TODO: change, unreliable
 			TotalDuration: d,
+			WarmupCount:   warmupCount,
 		},
 	}
 }
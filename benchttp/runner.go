@@ -17,6 +17,39 @@ type (
 	RecordingProgress = recorder.Progress
 	RecordingStatus   = recorder.Status
 
+	Stage = recorder.Stage
+
+	WeightedRequest = recorder.WeightedRequest
+
+	Scenario      = recorder.Scenario
+	Step          = recorder.Step
+	Extractor     = recorder.Extractor
+	ExtractorType = recorder.ExtractorType
+
+	Check     = recorder.Check
+	CheckType = recorder.CheckType
+
+	Feeder     = recorder.Feeder
+	FeederMode = recorder.FeederMode
+
+	Auth     = recorder.Auth
+	AuthType = recorder.AuthType
+
+	Signer      = recorder.Signer
+	HMACSigner  = recorder.HMACSigner
+	SigV4Signer = recorder.SigV4Signer
+
+	Protocol   = recorder.Protocol
+	TLSConfig  = recorder.TLSConfig
+	DialConfig = recorder.DialConfig
+	Proxy      = recorder.Proxy
+
+	VirtualUsers     = recorder.VirtualUsers
+	ConnectionPolicy = recorder.ConnectionPolicy
+
+	AbortCondition = recorder.AbortCondition
+	RunAbort       = recorder.Abort
+
 	MetricsAggregate = metrics.Aggregate
 	MetricsField     = metrics.Field
 	MetricsValue     = metrics.Value
@@ -33,19 +66,191 @@ const (
 	StatusCanceled = recorder.StatusCanceled
 	StatusTimeout  = recorder.StatusTimeout
 	StatusDone     = recorder.StatusDone
+	StatusAborted  = recorder.StatusAborted
+
+	ExtractJSON   = recorder.ExtractJSON
+	ExtractRegex  = recorder.ExtractRegex
+	ExtractHeader = recorder.ExtractHeader
+
+	CheckStatus      = recorder.CheckStatus
+	CheckHeader      = recorder.CheckHeader
+	CheckBodyRegex   = recorder.CheckBodyRegex
+	CheckJSONEquals  = recorder.CheckJSONEquals
+	CheckJSONExists  = recorder.CheckJSONExists
+	CheckMaxBodySize = recorder.CheckMaxBodySize
+
+	FeedSequential = recorder.FeedSequential
+	FeedRandom     = recorder.FeedRandom
+	FeedPerWorker  = recorder.FeedPerWorker
+
+	AuthBasic  = recorder.AuthBasic
+	AuthBearer = recorder.AuthBearer
+	AuthOAuth2 = recorder.AuthOAuth2
+
+	DefaultHMACHeader          = recorder.DefaultHMACHeader
+	DefaultHMACTimestampHeader = recorder.DefaultHMACTimestampHeader
+
+	ProtocolAuto  = recorder.ProtocolAuto
+	ProtocolHTTP1 = recorder.ProtocolHTTP1
+	ProtocolH2    = recorder.ProtocolH2
+	ProtocolH2C   = recorder.ProtocolH2C
+
+	ConnectionKeepAlive   = recorder.ConnectionKeepAlive
+	ConnectionNoKeepAlive = recorder.ConnectionNoKeepAlive
+	ConnectionPerRequest  = recorder.ConnectionPerRequest
 )
 
-var ErrCanceled = recorder.ErrCanceled
+var (
+	ErrCanceled       = recorder.ErrCanceled
+	ErrAuthentication = recorder.ErrAuthentication
+	ErrRender         = recorder.ErrRender
+)
 
 type Runner struct {
 	Request *http.Request
 
+	// RequestMix is a list of named requests sent in proportion
+	// to their weights, e.g. a realistic mix of endpoints.
+	// If set, Request must be nil and the metrics of each request
+	// are available in Report.Metrics.Requests by name.
+	RequestMix []WeightedRequest
+
+	// Scenario is an ordered list of steps run in sequence as a single
+	// iteration, with values extracted from the responses available
+	// to the next steps. If set, Request and RequestMix are ignored,
+	// Requests is the number of iterations, the metrics of each step
+	// are available in Report.Metrics.Requests by step name and
+	// the metrics of the iterations in Report.Metrics.Iterations.
+	Scenario Scenario
+
+	// Seed is the seed of the random values generated by the templates
+	// of the requests, e.g. {{uuid}} or {{randInt 1 100}}, and of the
+	// rows picked by Feeder in random mode. The values of an iteration
+	// are derived from the seed and the iteration index, so setting it
+	// reproduces the values of each iteration of a previous run, whatever
+	// the order the concurrent iterations ran in. If zero, a random seed
+	// is used and reported in Report.Metadata.Seed.
+	//
+	// The URL path and query, header values and template bodies (see
+	// BodyProvider.Template) of the requests are rendered as
+	// text/template templates for each request, with
+	// {{.Iteration}} the index of the iteration, {{.Worker}} the index
+	// of the concurrent worker running it, and the functions uuid,
+	// randInt min max and now.
+	Seed int64
+
+	// Feeder provides rows of data to the templates of the requests,
+	// e.g. distinct user IDs or API keys: the values of the row used
+	// by an iteration are rendered by column name, as in {{.userID}}.
+	// Each iteration uses the next row, a random row or the row
+	// of its worker, depending on Feeder.Mode.
+	Feeder Feeder
+
+	// Auth determines how requests are authenticated: basic auth,
+	// a static bearer token, or an OAuth2 token obtained with the client
+	// credentials grant. OAuth2 tokens are fetched before the run, then
+	// refreshed when they expire or are rejected with 401 Unauthorized.
+	// The time spent fetching tokens is not recorded. Requests that
+	// already have an Authorization header are sent unchanged.
+	Auth Auth
+
+	// Signer signs each request just before it is sent, after its
+	// templates are rendered and its authentication is set, e.g. with
+	// an HMACSigner or a SigV4Signer. The time spent signing is not
+	// part of the response times and is available in
+	// Report.Metrics.SigningTimes.
+	Signer Signer
+
+	// Checks are the validation rules of the responses: expected status
+	// codes, required headers, body regular expression, JSON values
+	// and maximum body size. A response failing any of them is invalid:
+	// its request is not successful, and it is counted in
+	// Report.Metrics.InvalidResponseCount and, by check name, in
+	// Report.Metrics.InvalidResponsesDistribution.
+	Checks []Check
+
+	// VirtualUsers determines the state owned by each concurrent worker,
+	// or virtual user, and kept across its iterations: its own connections,
+	// reused or not depending on VirtualUsers.Connection, and a cookie jar
+	// if VirtualUsers.Cookies is set.
+	VirtualUsers VirtualUsers
+
 	Requests       int
 	Concurrency    int
 	Interval       time.Duration
 	RequestTimeout time.Duration
 	GlobalTimeout  time.Duration
 
+	// Rate is the number of requests started per second.
+	// If set, requests are sent on a fixed timeline regardless of whether
+	// previous requests are done (open model), and Concurrency and Interval
+	// are ignored. If zero, Concurrency workers send requests as soon as
+	// their previous one is done (closed model).
+	Rate int
+
+	// Protocol is the HTTP protocol used to send requests: HTTP/1.1,
+	// HTTP/2 over TLS, HTTP/2 over cleartext (h2c) or, by default,
+	// HTTP/2 if negotiated over TLS and HTTP/1.1 otherwise.
+	// The protocols of the responses are reported in
+	// Report.Metrics.ProtocolsDistribution.
+	Protocol Protocol
+
+	// TLSConfig configures the TLS connections of the requests: trusted
+	// CAs, client certificate (mTLS), server name, versions and cipher
+	// suites. Its files are read when the runner is validated.
+	// The negotiated versions, cipher suites and session resumptions
+	// are reported in Report.Metrics.TLS*Distribution.
+	TLSConfig TLSConfig
+
+	// DialConfig configures how the connections of the requests are
+	// opened: host to address mappings overriding DNS resolution
+	// (like curl --resolve), DNS resolutions cached for the run,
+	// or a Unix domain socket all connections are opened to.
+	DialConfig DialConfig
+
+	// Proxy configures the HTTP CONNECT or SOCKS5 proxy the requests
+	// are sent through, with its credentials and the hosts bypassing it.
+	// It is only supported by protocols auto and http1. The tunnel
+	// establishments are reported as ProxyConnect events.
+	Proxy Proxy
+
+	// Duration is the duration of the run. If set, requests are sent
+	// until it is elapsed or Requests is reached. Use Requests = -1
+	// for a purely duration-based run. The requests in flight when it
+	// is elapsed are completed and recorded, within RequestTimeout.
+	Duration time.Duration
+
+	// Warmup is the duration from the start of the run during which
+	// requests are sent but their results are excluded from the metrics.
+	Warmup time.Duration
+	// WarmupRequests is the number of first requests whose results are
+	// excluded from the metrics. Warm-up requests count towards Requests.
+	WarmupRequests int
+
+	// Stages is the load profile of the run, e.g. a ramp-up, a plateau
+	// and a ramp-down. The level of load (Concurrency, or Rate in open model)
+	// ramps linearly from its configured value to the Target of the first
+	// Stage, then from one Stage's Target to the next one.
+	// If set, the run ends when all stages are done.
+	Stages []Stage
+
+	// AbortConditions are conditions on the latest results of the run,
+	// such as a failure rate or a latency percentile, that stop it early
+	// when any of them is met. The condition met is reported in
+	// Report.Metadata.Abort.
+	AbortConditions []AbortCondition
+
+	// Percentiles are the percentiles computed for each statistics of
+	// Report.Metrics, e.g. 95 or 99.9, addressable as fields such as
+	// ResponseTimes.P95 or ResponseTimes.P99_9. Defaults to
+	// 50, 90, 95, 99 and 99.9.
+	Percentiles []float64
+
+	// TimeSeriesWindow is the width of the time windows of
+	// Report.Metrics.TimeSeries. Defaults to 1s, and cannot be lower
+	// than 10ms. It is widened if the run spans more than 100000 windows.
+	TimeSeriesWindow time.Duration
+
 	Tests []tests.Case
 
 	OnProgress func(RecordingProgress)
@@ -71,9 +276,18 @@ func (r Runner) WithRequest(req *http.Request) Runner {
 	return r
 }
 
+// WithBody sets the body of the Runner's request to the body provided
+// by p, so that it can be sent multiple times. Runner.Request must be set.
+func (r Runner) WithBody(p BodyProvider) Runner {
+	SetRequestBody(r.Request, p)
+	return r
+}
+
 // WithNewRequest calls http.NewRequest with the given parameters
 // and attaches the result to the Runner. If the call to http.NewRequest
 // returns a non-nil error, it panics with the content of that error.
+// The body is only replayable if it is a *bytes.Buffer, *bytes.Reader
+// or *strings.Reader: use WithBody for other bodies.
 func (r Runner) WithNewRequest(method, uri string, body io.Reader) Runner {
 	req, err := http.NewRequest(method, uri, body)
 	if err != nil {
@@ -88,35 +302,108 @@ func (r Runner) Run(ctx context.Context) (*Report, error) {
 		return nil, err
 	}
 
+	if r.Seed == 0 {
+		r.Seed = time.Now().UnixNano()
+	}
+
 	// Create and attach request recorder
 	r.recorder = recorder.New(r.recorderConfig())
 
 	startTime := time.Now()
 
 	// Run request recorder
-	records, err := r.recorder.Record(ctx, r.Request)
+	records, err := r.record(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	duration := time.Since(startTime)
 
-	agg := metrics.NewAggregate(records)
+	records, numWarmup := excludeWarmup(records)
+
+	agg := metrics.NewAggregate(records, r.Percentiles...)
+	agg.TimeSeries = metrics.NewTimeSeries(records, r.TimeSeriesWindow, r.Percentiles...)
+	if r.hasScenario() {
+		agg.Iterations = metrics.NewIterationAggregate(
+			excludeWarmupIterations(r.recorder.Iterations()),
+			r.Percentiles...,
+		)
+	}
 
 	testResults := tests.Run(agg, r.Tests)
 
-	return newReport(r, duration, agg, testResults), nil
+	report := newReport(r, duration, numWarmup, agg, testResults)
+	report.Metadata.Abort = r.recorder.Aborted()
+	report.Metadata.Seed = r.Seed
+
+	return report, nil
+}
+
+// record runs the recorder with r.Scenario if set, else with r.RequestMix
+// if set, else with r.Request.
+func (r Runner) record(ctx context.Context) ([]recorder.Record, error) {
+	if r.hasScenario() {
+		return r.recorder.RecordScenario(ctx, r.Scenario)
+	}
+	if len(r.RequestMix) > 0 {
+		return r.recorder.RecordMix(ctx, r.RequestMix)
+	}
+	return r.recorder.Record(ctx, r.Request)
+}
+
+// excludeWarmup returns the records that are not part of the warm-up
+// and the number of excluded records.
+func excludeWarmup(records []recorder.Record) ([]recorder.Record, int) {
+	kept := make([]recorder.Record, 0, len(records))
+	for _, rec := range records {
+		if !rec.Warmup {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, len(records) - len(kept)
+}
+
+// excludeWarmupIterations returns the iterations that are not part
+// of the warm-up.
+func excludeWarmupIterations(iterations []recorder.IterationRecord) []recorder.IterationRecord {
+	kept := make([]recorder.IterationRecord, 0, len(iterations))
+	for _, iter := range iterations {
+		if !iter.Warmup {
+			kept = append(kept, iter)
+		}
+	}
+	return kept
+}
+
+func (r Runner) hasScenario() bool {
+	return len(r.Scenario.Steps) > 0
 }
 
 // recorderConfig returns a runner.RequesterConfig generated from cfg.
 func (r Runner) recorderConfig() recorder.Config {
 	return recorder.Config{
-		Requests:       r.Requests,
-		Concurrency:    r.Concurrency,
-		Interval:       r.Interval,
-		RequestTimeout: r.RequestTimeout,
-		GlobalTimeout:  r.GlobalTimeout,
-		OnProgress:     r.OnProgress,
+		Requests:        r.Requests,
+		Concurrency:     r.Concurrency,
+		Interval:        r.Interval,
+		Rate:            r.Rate,
+		Protocol:        r.Protocol,
+		TLS:             r.TLSConfig,
+		Dial:            r.DialConfig,
+		Proxy:           r.Proxy,
+		RequestTimeout:  r.RequestTimeout,
+		GlobalTimeout:   r.GlobalTimeout,
+		Duration:        r.Duration,
+		Stages:          r.Stages,
+		Warmup:          r.Warmup,
+		WarmupRequests:  r.WarmupRequests,
+		Seed:            r.Seed,
+		Feeder:          r.Feeder,
+		Auth:            r.Auth,
+		Signer:          r.Signer,
+		Checks:          r.Checks,
+		VirtualUsers:    r.VirtualUsers,
+		AbortConditions: r.AbortConditions,
+		OnProgress:      r.OnProgress,
 	}
 }
 
@@ -128,25 +415,87 @@ func (r Runner) Validate() error { //nolint:gocognit
 		errs = append(errs, err)
 	}
 
-	if r.Request == nil {
+	if r.Request == nil && len(r.RequestMix) == 0 && !r.hasScenario() {
 		appendError(errors.New("Runner.Request must not be nil"))
 	}
 
+	if r.Request != nil && len(r.RequestMix) == 0 && !r.hasScenario() && !isReplayable(r.Request) {
+		appendError(errNotReplayable("request.body"))
+	}
+
+	if r.Request != nil && len(r.RequestMix) > 0 && !r.hasScenario() {
+		appendError(errors.New("request: want nil when requestMix is set"))
+	}
+
+	mixNames := map[string]bool{}
+	for i, wr := range r.RequestMix {
+		if wr.Name == "" || mixNames[wr.Name] {
+			appendError(fmt.Errorf("requestMix[%d].name (%q): want non-empty and unique", i, wr.Name))
+		}
+		mixNames[wr.Name] = true
+		if wr.Weight < 1 {
+			appendError(fmt.Errorf("requestMix[%d].weight (%d): want > 0", i, wr.Weight))
+		}
+		if wr.Request == nil {
+			appendError(fmt.Errorf("requestMix[%d].request: want non-nil", i))
+		} else if !isReplayable(wr.Request) {
+			appendError(errNotReplayable(fmt.Sprintf("requestMix[%d].request.body", i)))
+		}
+	}
+
 	if r.Requests < 1 && r.Requests != -1 {
 		appendError(fmt.Errorf("requests (%d): want >= 0", r.Requests))
 	}
 
-	if r.Concurrency < 1 || r.Concurrency > r.Requests {
+	// Concurrency is ignored in open model.
+	if r.Rate < 1 && (r.Concurrency < 1 || (r.Concurrency > r.Requests && r.Requests != -1)) {
 		appendError(fmt.Errorf(
 			"concurrency (%d): want > 0 and <= requests (%d)",
 			r.Concurrency, r.Requests,
 		))
 	}
 
+	if r.Rate < 0 {
+		appendError(fmt.Errorf("rate (%d): want >= 0", r.Rate))
+	}
+
+	if err := r.Protocol.Validate(); err != nil {
+		appendError(fmt.Errorf("protocol: %s", err))
+	}
+
+	if err := r.TLSConfig.Validate(); err != nil {
+		appendError(fmt.Errorf("tls: %s", err))
+	}
+
+	if err := r.DialConfig.Validate(); err != nil {
+		appendError(fmt.Errorf("dial: %s", err))
+	} else if r.DialConfig.UnixSocket != "" && r.Proxy.URL != "" {
+		appendError(errors.New("dial.unixSocket: not supported with proxy"))
+	}
+
+	if err := r.Proxy.Validate(); err != nil {
+		appendError(fmt.Errorf("proxy: %s", err))
+	} else if r.Proxy.URL != "" && (r.Protocol == ProtocolH2 || r.Protocol == ProtocolH2C) {
+		appendError(fmt.Errorf("proxy: not supported with protocol %q", r.Protocol))
+	}
+
 	if r.Interval < 0 {
 		appendError(fmt.Errorf("interval (%d): want >= 0", r.Interval))
 	}
 
+	for i, p := range r.Percentiles {
+		if p < 0 || p > 100 {
+			appendError(fmt.Errorf("percentiles[%d] (%v): want >= 0 and <= 100", i, p))
+		}
+	}
+
+	if r.TimeSeriesWindow < 0 || (r.TimeSeriesWindow > 0 && r.TimeSeriesWindow < metrics.MinTimeSeriesWindow) {
+		appendError(fmt.Errorf(
+			"timeSeriesWindow (%d): want 0 or >= %v",
+			r.TimeSeriesWindow, metrics.MinTimeSeriesWindow,
+		))
+	}
+
 	if r.RequestTimeout < 1 {
 		appendError(fmt.Errorf("requestTimeout (%d): want > 0", r.RequestTimeout))
 	}
@@ -155,6 +504,114 @@ func (r Runner) Validate() error { //nolint:gocognit
 		appendError(fmt.Errorf("globalTimeout (%d): want > 0", r.GlobalTimeout))
 	}
 
+	if r.Duration < 0 || r.Duration > r.GlobalTimeout {
+		appendError(fmt.Errorf(
+			"duration (%d): want >= 0 and <= globalTimeout (%d)",
+			r.Duration, r.GlobalTimeout,
+		))
+	}
+
+	if r.Warmup < 0 || (r.Duration > 0 && r.Warmup >= r.Duration) {
+		appendError(fmt.Errorf(
+			"warmup (%d): want >= 0 and < duration (%d) if set",
+			r.Warmup, r.Duration,
+		))
+	}
+
+	if r.WarmupRequests < 0 || (r.Requests > 0 && r.WarmupRequests >= r.Requests) {
+		appendError(fmt.Errorf(
+			"warmupRequests (%d): want >= 0 and < requests (%d)",
+			r.WarmupRequests, r.Requests,
+		))
+	}
+
+	for i, stage := range r.Stages {
+		if stage.Duration < 1 {
+			appendError(fmt.Errorf("stages[%d].duration (%d): want > 0", i, stage.Duration))
+		}
+		if stage.Target < 0 {
+			appendError(fmt.Errorf("stages[%d].target (%d): want >= 0", i, stage.Target))
+		}
+	}
+
+	stepNames := map[string]bool{}
+	for i, step := range r.Scenario.Steps {
+		if step.Name == "" || stepNames[step.Name] {
+			appendError(fmt.Errorf("scenario.steps[%d].name (%q): want non-empty and unique", i, step.Name))
+		}
+		stepNames[step.Name] = true
+		if step.Request == nil {
+			appendError(fmt.Errorf("scenario.steps[%d].request: want non-nil", i))
+		} else if !isReplayable(step.Request) {
+			appendError(errNotReplayable(fmt.Sprintf("scenario.steps[%d].request.body", i)))
+		}
+		for j, e := range step.Extractors {
+			if e.Var == "" {
+				appendError(fmt.Errorf("scenario.steps[%d].extractors[%d].var: want non-empty", i, j))
+			}
+			if err := e.Validate(); err != nil {
+				appendError(fmt.Errorf("scenario.steps[%d].extractors[%d]: %s", i, j, err))
+			}
+		}
+	}
+
+	if err := r.Feeder.Validate(); err != nil {
+		appendError(fmt.Errorf("feeder: %s", err))
+	}
+
+	if err := r.Auth.Validate(); err != nil {
+		appendError(fmt.Errorf("auth: %s", err))
+	}
+
+	for i, c := range r.Checks {
+		if err := c.Validate(); err != nil {
+			appendError(fmt.Errorf("checks[%d]: %s", i, err))
+		}
+	}
+
+	for i, c := range r.Tests {
+		if err := c.Field.Validate(r.Percentiles...); err != nil {
+			appendError(fmt.Errorf("tests[%d].field: %s", i, err))
+		}
+	}
+
+	if err := r.VirtualUsers.Validate(); err != nil {
+		appendError(fmt.Errorf("virtualUsers: %s", err))
+	}
+
+	if v, ok := r.Signer.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			appendError(fmt.Errorf("signer: %s", err))
+		}
+	}
+
+	for i, c := range r.AbortConditions {
+		if c.FailureRate == 0 && c.Latency == 0 {
+			appendError(fmt.Errorf("abortConditions[%d]: want failureRate or latency", i))
+		}
+		if c.FailureRate < 0 || c.FailureRate > 1 {
+			appendError(fmt.Errorf(
+				"abortConditions[%d].failureRate (%v): want > 0 and <= 1",
+				i, c.FailureRate,
+			))
+		}
+		if c.Latency < 0 {
+			appendError(fmt.Errorf("abortConditions[%d].latency (%d): want > 0", i, c.Latency))
+		}
+		if c.Latency > 0 && (c.Percentile < 1 || c.Percentile > 100) {
+			appendError(fmt.Errorf(
+				"abortConditions[%d].percentile (%d): want > 0 and <= 100",
+				i, c.Percentile,
+			))
+		}
+		if c.WindowRequests < 1 && c.WindowDuration < 1 {
+			appendError(fmt.Errorf(
+				"abortConditions[%d]: want windowRequests > 0 or windowDuration > 0",
+				i,
+			))
+		}
+	}
+
 	if len(errs) > 0 {
 		return &InvalidRunnerError{errs}
 	}
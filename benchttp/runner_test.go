@@ -1,9 +1,17 @@
 package benchttp_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"math/rand"
+	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/benchttp/engine/benchttp"
 )
@@ -55,14 +63,62 @@ func TestRunner_Validate(t *testing.T) {
 		}
 	})
 
+	t.Run("return nil for a duration-based run", func(t *testing.T) {
+		runner := benchttp.Runner{
+			Request:        httptest.NewRequest("GET", "https://a.b/#c?d=e&f=g", nil),
+			Requests:       -1,
+			Concurrency:    5,
+			Duration:       4,
+			Warmup:         2,
+			WarmupRequests: 5,
+			RequestTimeout: 5,
+			GlobalTimeout:  5,
+		}
+
+		if err := runner.Validate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ignore concurrency in open model", func(t *testing.T) {
+		runner := benchttp.Runner{
+			Request:        httptest.NewRequest("GET", "https://a.b/#c?d=e&f=g", nil),
+			Requests:       5,
+			Concurrency:    0,
+			Rate:           100,
+			RequestTimeout: 5,
+			GlobalTimeout:  5,
+		}
+
+		if err := runner.Validate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
 	t.Run("return cumulated errors if config is invalid", func(t *testing.T) {
 		runner := benchttp.Runner{
-			Request:        nil,
-			Requests:       -5,
-			Concurrency:    -5,
-			Interval:       -5,
-			RequestTimeout: -5,
-			GlobalTimeout:  -5,
+			Request:          nil,
+			Requests:         -5,
+			Concurrency:      -5,
+			Interval:         -5,
+			RequestTimeout:   -5,
+			GlobalTimeout:    -5,
+			Rate:             -5,
+			Protocol:         "h3",
+			Duration:         -5,
+			Warmup:           -5,
+			WarmupRequests:   -5,
+			TimeSeriesWindow: -5,
+			Percentiles:      []float64{99.9, 101},
+			Tests: []benchttp.TestCase{
+				{Field: "ResponseTimes.P99_9"},
+				{Field: "ResponseTimes.P99"},
+			},
+			Stages: []benchttp.Stage{{Duration: 5, Target: 5}, {Duration: -5, Target: -5}},
+			AbortConditions: []benchttp.AbortCondition{
+				{},
+				{FailureRate: 2, Latency: 5, Percentile: 0, WindowRequests: 10},
+			},
 		}
 
 		err := runner.Validate()
@@ -81,10 +137,410 @@ func TestRunner_Validate(t *testing.T) {
 		assertError(t, errs, "concurrency (-5): want > 0 and <= requests (-5)")
 		assertError(t, errs, "interval (-5): want >= 0")
 		assertError(t, errs, "requestTimeout (-5): want > 0")
+		assertError(t, errs, "timeSeriesWindow (-5): want 0 or >= 10ms")
+		assertError(t, errs, "percentiles[1] (101): want >= 0 and <= 100")
+		assertError(t, errs, "tests[1].field: metrics: unknown field: ResponseTimes.P99: percentile P99 is not computed")
 		assertError(t, errs, "globalTimeout (-5): want > 0")
+		assertError(t, errs, "rate (-5): want >= 0")
+		assertError(t, errs, `protocol: unknown protocol "h3": want "auto", "http1", "h2" or "h2c"`)
+		assertError(t, errs, "duration (-5): want >= 0 and <= globalTimeout (-5)")
+		assertError(t, errs, "warmup (-5): want >= 0 and < duration (-5) if set")
+		assertError(t, errs, "warmupRequests (-5): want >= 0 and < requests (-5)")
+		assertError(t, errs, "stages[1].duration (-5): want > 0")
+		assertError(t, errs, "stages[1].target (-5): want >= 0")
+		assertError(t, errs, "abortConditions[0]: want failureRate or latency")
+		assertError(t, errs, "abortConditions[0]: want windowRequests > 0 or windowDuration > 0")
+		assertError(t, errs, "abortConditions[1].failureRate (2): want > 0 and <= 1")
+		assertError(t, errs, "abortConditions[1].percentile (0): want > 0 and <= 100")
 
 		t.Logf("got error:\n%v", errInvalid)
 	})
+
+	t.Run("return errors for invalid scenario", func(t *testing.T) {
+		runner := benchttp.DefaultRunner()
+		runner.Scenario = benchttp.Scenario{Steps: []benchttp.Step{
+			{Name: "a", Request: &http.Request{}},
+			{
+				Name: "",
+				Extractors: []benchttp.Extractor{
+					{Var: "", Type: benchttp.ExtractRegex, Expression: "("},
+					{Var: "v", Type: "xml"},
+				},
+			},
+		}}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		errs := errInvalid.Errors
+		assertError(t, errs, `scenario.steps[1].name (""): want non-empty and unique`)
+		assertError(t, errs, "scenario.steps[1].request: want non-nil")
+		assertError(t, errs, "scenario.steps[1].extractors[0].var: want non-empty")
+		assertError(t, errs, "scenario.steps[1].extractors[0]: error parsing regexp: missing closing ): `(`")
+		assertError(t, errs, `scenario.steps[1].extractors[1]: unknown type "xml": want "json", "regex" or "header"`)
+	})
+
+	t.Run("return errors for non-replayable bodies", func(t *testing.T) {
+		nonReplayable := func() *http.Request {
+			req, _ := http.NewRequest("POST", "http://a.b", io.NopCloser(strings.NewReader("x")))
+			return req
+		}
+
+		runner := benchttp.DefaultRunner().WithRequest(nonReplayable())
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertError(t, errInvalid.Errors, "request.body: want a replayable body (non-nil GetBody), e.g. set via SetRequestBody")
+
+		runner.RequestMix = []benchttp.WeightedRequest{{Name: "a", Weight: 1, Request: nonReplayable()}}
+		runner.Scenario = benchttp.Scenario{Steps: []benchttp.Step{{Name: "a", Request: nonReplayable()}}}
+
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		errs := errInvalid.Errors
+		if len(errs) != 2 {
+			t.Errorf("exp 2 errors (ignored Request), got %v", errInvalid)
+		}
+		assertError(t, errs, "requestMix[0].request.body: want a replayable body (non-nil GetBody), e.g. set via SetRequestBody")
+		assertError(t, errs, "scenario.steps[0].request.body: want a replayable body (non-nil GetBody), e.g. set via SetRequestBody")
+	})
+
+	t.Run("return errors for invalid feeder", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.Feeder = benchttp.Feeder{
+			Rows: []map[string]string{{"id": "1"}},
+			Mode: "circular",
+		}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `feeder: unknown mode "circular": want "sequential", "random" or "perWorker"`)
+	})
+
+	t.Run("return errors for invalid auth", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.Auth = benchttp.Auth{Type: benchttp.AuthOAuth2, TokenURL: "/token"}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `auth: tokenURL ("/token"): want an absolute URL for type oauth2`)
+	})
+
+	t.Run("return errors for invalid tls config", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "https://a.b", nil)
+		runner.TLSConfig = benchttp.TLSConfig{CertFile: "client.pem"}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, "tls: certFile, keyFile: want both or none")
+	})
+
+	t.Run("return errors for invalid checks", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.Checks = []benchttp.Check{
+			{Type: benchttp.CheckStatus, Expression: "2xx"},
+			{Type: benchttp.CheckStatus, Expression: "6xx"},
+		}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `checks[1]: invalid status class "6xx": want 1xx to 5xx`)
+	})
+
+	t.Run("return errors for invalid dial config", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.DialConfig = benchttp.DialConfig{Resolve: map[string]string{"a.b": ""}}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `dial: resolve["a.b"]: want non-empty address`)
+
+		runner.DialConfig = benchttp.DialConfig{UnixSocket: "/var/run/app.sock"}
+		runner.Proxy = benchttp.Proxy{URL: "http://proxy.internal"}
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, "dial.unixSocket: not supported with proxy")
+	})
+
+	t.Run("return errors for invalid proxy config", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "https://a.b", nil)
+		runner.Proxy = benchttp.Proxy{URL: "ftp://proxy.internal"}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `proxy: url ("ftp://proxy.internal"): want scheme "http", "https" or "socks5"`)
+
+		runner.Proxy = benchttp.Proxy{URL: "http://proxy.internal"}
+		runner.Protocol = benchttp.ProtocolH2
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `proxy: not supported with protocol "h2"`)
+	})
+
+	t.Run("return errors for invalid virtual users", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.VirtualUsers = benchttp.VirtualUsers{Connection: "pooled"}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `virtualUsers: connection: unknown policy "pooled": want "keepAlive", "noKeepAlive" or "perRequest"`)
+	})
+
+	t.Run("return errors for invalid signer", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.Signer = benchttp.SigV4Signer{AccessKeyID: "id", SecretAccessKey: "secret"}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, `signer: region: want non-empty`)
+	})
+
+	t.Run("return error for request and request mix", func(t *testing.T) {
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", "http://a.b", nil)
+		runner.RequestMix = []benchttp.WeightedRequest{
+			{Name: "a", Weight: 1, Request: &http.Request{}},
+		}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertError(t, errInvalid.Errors, "request: want nil when requestMix is set")
+	})
+
+	t.Run("return errors for invalid request mix", func(t *testing.T) {
+		runner := benchttp.DefaultRunner()
+		runner.RequestMix = []benchttp.WeightedRequest{
+			{Name: "a", Weight: 1, Request: &http.Request{}},
+			{Name: "a", Weight: 0, Request: nil},
+		}
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		errs := errInvalid.Errors
+		if len(errs) != 3 {
+			t.Errorf("unexpected errors: exp 3 errors, got %v", errInvalid)
+		}
+		assertError(t, errs, `requestMix[1].name ("a"): want non-empty and unique`)
+		assertError(t, errs, "requestMix[1].weight (0): want > 0")
+		assertError(t, errs, "requestMix[1].request: want non-nil")
+	})
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Run("abort when a condition is met", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+		}))
+		defer srv.Close()
+
+		runner := benchttp.DefaultRunner().WithNewRequest("GET", srv.URL, nil)
+		runner.Concurrency = 1
+		runner.AbortConditions = []benchttp.AbortCondition{{
+			Latency:        10 * time.Millisecond,
+			Percentile:     50,
+			WindowRequests: 5,
+		}}
+
+		report, err := runner.Run(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		abort := report.Metadata.Abort
+		if abort == nil {
+			t.Fatal("exp run to be aborted, got nil Metadata.Abort")
+		}
+		if abort.Condition != runner.AbortConditions[0] {
+			t.Errorf("unexpected abort condition: %v", abort.Condition)
+		}
+
+		if n := report.Metrics.RequestCount(); n != 5 {
+			t.Errorf("unexpected request count: exp 5, got %d", n)
+		}
+	})
+
+	t.Run("send requests with http.NoBody", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer srv.Close()
+
+		runner := benchttp.DefaultRunner().WithNewRequest("POST", srv.URL, http.NoBody)
+		runner.Requests = 3
+		runner.Concurrency = 1
+
+		report, err := runner.Run(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n := report.Metrics.RequestSuccessCount(); n != 3 {
+			t.Errorf("unexpected success count: exp 3, got %d", n)
+		}
+	})
+}
+
+func TestRunner_Run_templates(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		gotVals []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// Vary the response times to shuffle the order the steps
+		// of the concurrent iterations are rendered in.
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond) //nolint:gosec
+
+		mu.Lock()
+		defer mu.Unlock()
+		gotVals = append(gotVals, r.URL.Path+" "+r.Header.Get("X-Request-Id"))
+	}))
+	defer srv.Close()
+
+	newRequest := func(step string) *http.Request {
+		req, _ := http.NewRequest("GET", srv.URL+"/"+step+"/{{.Iteration}}/{{.user}}", nil)
+		req.Header.Set("X-Request-Id", "{{uuid}}")
+		return req
+	}
+
+	run := func(seed int64) (*benchttp.Report, []string) {
+		gotVals = nil
+		runner := benchttp.DefaultRunner()
+		runner.Requests = 20
+		runner.Concurrency = 4
+		runner.Seed = seed
+		runner.Scenario = benchttp.Scenario{Steps: []benchttp.Step{
+			{Name: "first", Request: newRequest("first")},
+			{Name: "second", Request: newRequest("second")},
+		}}
+		runner.Feeder = benchttp.Feeder{
+			Rows: []map[string]string{{"user": "alice"}, {"user": "bob"}, {"user": "carol"}},
+			Mode: benchttp.FeedRandom,
+		}
+
+		report, err := runner.Run(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The iterations run concurrently, in any order.
+		sort.Strings(gotVals)
+		return report, gotVals
+	}
+
+	report, vals := run(0)
+	if report.Metadata.Seed == 0 {
+		t.Error("exp random seed to be reported, got 0")
+	}
+	if vals[1] == vals[2] {
+		t.Errorf("exp distinct values for each request, got %q twice", vals[1])
+	}
+
+	_, replayed := run(report.Metadata.Seed)
+	if strings.Join(replayed, "\n") != strings.Join(vals, "\n") {
+		t.Errorf("exp same values with same seed:\n%v\n%v", vals, replayed)
+	}
+}
+
+func TestRunner_Run_scenario(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"token":"abc"}`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Order-Id", "42")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	newRequest := func(uri string) *http.Request {
+		req, _ := http.NewRequest("GET", srv.URL+uri, nil)
+		return req
+	}
+
+	orders := newRequest("/orders")
+	orders.Header.Set("Authorization", "Bearer {{.token}}")
+
+	runner := benchttp.DefaultRunner()
+	runner.Requests = 4
+	runner.Concurrency = 2
+	runner.Scenario = benchttp.Scenario{Steps: []benchttp.Step{
+		{
+			Name:    "login",
+			Request: newRequest("/login"),
+			Extractors: []benchttp.Extractor{
+				{Var: "token", Type: benchttp.ExtractJSON, Expression: "/token"},
+			},
+		},
+		{
+			Name:    "orders",
+			Request: orders,
+			Extractors: []benchttp.Extractor{
+				{Var: "id", Type: benchttp.ExtractHeader, Expression: "X-Order-Id"},
+			},
+		},
+	}}
+	runner.Tests = []benchttp.TestCase{{
+		Name:      "authorized orders",
+		Field:     "Requests.orders.StatusCodesDistribution.200",
+		Predicate: "EQ",
+		Target:    4,
+	}}
+
+	report, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !report.Tests.Pass {
+		t.Errorf("unexpected test results: %+v", report.Tests)
+	}
+
+	if n := report.Metrics.RequestCount(); n != 8 {
+		t.Errorf("unexpected request count: exp 8, got %d", n)
+	}
+
+	if iters := report.Metrics.Iterations; iters.Count != 4 || iters.FailureCount != 0 {
+		t.Errorf("unexpected iterations metrics: %+v", iters)
+	}
 }
 
 // helpers
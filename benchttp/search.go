@@ -0,0 +1,237 @@
+package benchttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// SearchMode is the strategy used by a CapacitySearch
+// to determine the next level of load to run.
+type SearchMode string
+
+const (
+	// SearchLinear increases the level of load by Step
+	// until the tests fail or Max is reached.
+	SearchLinear SearchMode = "linear"
+	// SearchBinary halves the searched interval of levels at each step,
+	// up to a precision of Step.
+	SearchBinary SearchMode = "binary"
+)
+
+// SearchTarget is the Runner setting varied by a CapacitySearch.
+type SearchTarget string
+
+const (
+	// SearchConcurrency varies Runner.Concurrency.
+	SearchConcurrency SearchTarget = "concurrency"
+	// SearchRate varies Runner.Rate.
+	SearchRate SearchTarget = "rate"
+)
+
+// CapacitySearch runs a Runner repeatedly with different levels of load
+// to find the highest one at which the Runner's Tests still pass,
+// i.e. the maximum sustainable throughput under the given objectives.
+//
+// The search assumes that if the tests fail at a given level,
+// they also fail at any higher level.
+type CapacitySearch struct {
+	// Runner is the base Runner run at each step. Its Tests define
+	// whether a level of load is sustainable.
+	Runner Runner
+
+	Mode   SearchMode
+	Target SearchTarget
+
+	// Min and Max are the bounds of the searched levels, inclusive.
+	// With target concurrency, Max cannot exceed Runner.Requests
+	// and Runner.Rate must be 0.
+	Min, Max int
+	// Step is the increment between two levels in linear mode,
+	// and the precision of the result in binary mode.
+	Step int
+}
+
+// SearchReport represents the result of a CapacitySearch.
+type SearchReport struct {
+	// Level is the highest level of load at which the tests passed,
+	// or 0 if they did not pass at any level.
+	Level int
+	// Steps lists the runs of the search in order.
+	Steps []SearchStep
+}
+
+// SearchStep is the result of a single run of a CapacitySearch.
+type SearchStep struct {
+	Level   int
+	Pass    bool
+	Metrics MetricsAggregate
+	Tests   TestSuiteResults
+}
+
+// Table returns a text table summarizing the metrics of each step.
+func (r SearchReport) Table() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEVEL\tREQUESTS\tFAILURES\tMEAN\tMAX\tPASS")
+	for _, step := range r.Steps {
+		m := step.Metrics
+		fmt.Fprintf(w, "%d\t%d\t%d\t%v\t%v\t%v\n",
+			step.Level, m.RequestCount(), m.RequestFailureCount(),
+			m.ResponseTimes.Mean, m.ResponseTimes.Max, step.Pass,
+		)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Run runs the search and returns the resulting SearchReport.
+// It returns early a non-nil error if the search is invalid
+// or if any of its runs fails.
+func (s CapacitySearch) Run(ctx context.Context) (*SearchReport, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &SearchReport{}
+
+	runStep := func(level int) (bool, error) {
+		stepReport, err := s.runnerAt(level).Run(ctx)
+		if err != nil {
+			return false, fmt.Errorf("search level %d: %w", level, err)
+		}
+		pass := stepReport.Tests.Pass
+		report.Steps = append(report.Steps, SearchStep{
+			Level:   level,
+			Pass:    pass,
+			Metrics: stepReport.Metrics,
+			Tests:   stepReport.Tests,
+		})
+		if pass && level > report.Level {
+			report.Level = level
+		}
+		return pass, nil
+	}
+
+	var err error
+	switch s.Mode {
+	case SearchLinear:
+		err = s.searchLinear(runStep)
+	case SearchBinary:
+		err = s.searchBinary(runStep)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// searchLinear calls runStep with increasing levels from s.Min
+// until it fails or s.Max is reached. The last level is s.Max
+// even if it is not a multiple of s.Step away from s.Min.
+func (s CapacitySearch) searchLinear(runStep func(int) (bool, error)) error {
+	for level := s.Min; ; level += s.Step {
+		if level > s.Max {
+			level = s.Max
+		}
+		pass, err := runStep(level)
+		if err != nil || !pass || level == s.Max {
+			return err
+		}
+	}
+}
+
+// searchBinary calls runStep with levels converging towards the highest
+// passing one in [s.Min, s.Max], with a precision of s.Step.
+func (s CapacitySearch) searchBinary(runStep func(int) (bool, error)) error {
+	lo, hi := s.Min, s.Max
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		pass, err := runStep(mid)
+		if err != nil {
+			return err
+		}
+		if pass {
+			lo = mid + s.Step
+		} else {
+			hi = mid - s.Step
+		}
+	}
+	return nil
+}
+
+// runnerAt returns a copy of s.Runner with its target setting set to level.
+func (s CapacitySearch) runnerAt(level int) Runner {
+	r := s.Runner
+	switch s.Target {
+	case SearchConcurrency:
+		r.Concurrency = level
+	case SearchRate:
+		r.Rate = level
+	}
+	return r
+}
+
+// Validate returns a non-nil InvalidRunnerError if any of the search
+// fields does not meet the requirements. The base Runner is validated
+// at each step of the search.
+func (s CapacitySearch) Validate() error {
+	errs := []error{}
+	appendError := func(err error) {
+		errs = append(errs, err)
+	}
+
+	if s.Mode != SearchLinear && s.Mode != SearchBinary {
+		appendError(fmt.Errorf(
+			"mode (%q): want %q or %q",
+			s.Mode, SearchLinear, SearchBinary,
+		))
+	}
+
+	if s.Target != SearchConcurrency && s.Target != SearchRate {
+		appendError(fmt.Errorf(
+			"target (%q): want %q or %q",
+			s.Target, SearchConcurrency, SearchRate,
+		))
+	}
+
+	if s.Min < 1 || s.Min > s.Max {
+		appendError(fmt.Errorf("min (%d): want > 0 and <= max (%d)", s.Min, s.Max))
+	}
+
+	if s.Step < 1 {
+		appendError(fmt.Errorf("step (%d): want > 0", s.Step))
+	}
+
+	// Concurrency is ignored by a Runner with a fixed rate,
+	// so each step would run the same load.
+	if s.Target == SearchConcurrency && s.Runner.Rate > 0 {
+		appendError(fmt.Errorf(
+			"runner.rate (%d): want 0 for target %q",
+			s.Runner.Rate, SearchConcurrency,
+		))
+	}
+
+	// Each step would fail the validation of the Runner
+	// with a concurrency exceeding its requests.
+	if s.Target == SearchConcurrency && s.Runner.Rate < 1 &&
+		s.Runner.Requests != -1 && s.Max > s.Runner.Requests {
+		appendError(fmt.Errorf(
+			"max (%d): want <= runner.requests (%d) for target %q",
+			s.Max, s.Runner.Requests, SearchConcurrency,
+		))
+	}
+
+	if len(s.Runner.Tests) == 0 {
+		appendError(errors.New("runner.tests: want at least 1 test case"))
+	}
+
+	if len(errs) > 0 {
+		return &InvalidRunnerError{errs}
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package benchttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benchttp/engine/benchttp"
+)
+
+func TestCapacitySearch_Run(t *testing.T) {
+	// maxCapacity is the maximum number of concurrent requests
+	// the test server handles without failing.
+	const maxCapacity = 4
+
+	srv := httptest.NewServer(limitedHandler(maxCapacity))
+	defer srv.Close()
+
+	baseRunner := benchttp.DefaultRunner().WithNewRequest("GET", srv.URL, nil)
+	baseRunner.Requests = 16
+	baseRunner.Tests = []benchttp.TestCase{{
+		Name:      "no server error",
+		Field:     "StatusCodesDistribution.503",
+		Predicate: benchttp.TestPredicate("EQ"),
+		Target:    0,
+	}}
+
+	for _, tc := range []struct {
+		label         string
+		mode          benchttp.SearchMode
+		max, step     int
+		expStepLevels []int
+	}{
+		{
+			label:         "linear",
+			mode:          benchttp.SearchLinear,
+			max:           8,
+			step:          1,
+			expStepLevels: []int{1, 2, 3, 4, 5},
+		},
+		{
+			label:         "linear with last step clamped to max",
+			mode:          benchttp.SearchLinear,
+			max:           4,
+			step:          2,
+			expStepLevels: []int{1, 3, 4},
+		},
+		{
+			label:         "binary",
+			mode:          benchttp.SearchBinary,
+			max:           8,
+			step:          1,
+			expStepLevels: []int{4, 6, 5},
+		},
+	} {
+		t.Run(tc.label, func(t *testing.T) {
+			search := benchttp.CapacitySearch{
+				Runner: baseRunner,
+				Mode:   tc.mode,
+				Target: benchttp.SearchConcurrency,
+				Min:    1,
+				Max:    tc.max,
+				Step:   tc.step,
+			}
+
+			report, err := search.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if report.Level != maxCapacity {
+				t.Errorf("level: exp %d, got %d", maxCapacity, report.Level)
+			}
+
+			gotStepLevels := make([]int, len(report.Steps))
+			for i, step := range report.Steps {
+				gotStepLevels[i] = step.Level
+				if step.Pass != (step.Level <= maxCapacity) {
+					t.Errorf("step %d: unexpected pass value: %v", step.Level, step.Pass)
+				}
+			}
+			if !equalInts(gotStepLevels, tc.expStepLevels) {
+				t.Errorf("step levels: exp %v, got %v", tc.expStepLevels, gotStepLevels)
+			}
+
+			if table := report.Table(); strings.Count(table, "\n") != len(report.Steps)+1 {
+				t.Errorf("unexpected table:\n%s", table)
+			}
+		})
+	}
+}
+
+func TestCapacitySearch_Validate(t *testing.T) {
+	search := benchttp.CapacitySearch{
+		Mode:   "random",
+		Target: "requests",
+		Min:    10,
+		Max:    5,
+		Step:   0,
+	}
+
+	err := search.Validate()
+
+	var errInvalid *benchttp.InvalidRunnerError
+	if !errors.As(err, &errInvalid) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+
+	errs := errInvalid.Errors
+	assertError(t, errs, `mode ("random"): want "linear" or "binary"`)
+	assertError(t, errs, `target ("requests"): want "concurrency" or "rate"`)
+	assertError(t, errs, "min (10): want > 0 and <= max (5)")
+	assertError(t, errs, "step (0): want > 0")
+	assertError(t, errs, "runner.tests: want at least 1 test case")
+
+	t.Run("max concurrency exceeding requests", func(t *testing.T) {
+		runner := benchttp.DefaultRunner()
+		runner.Requests = 10
+		runner.Tests = []benchttp.TestCase{{
+			Name:      "no server error",
+			Field:     "StatusCodesDistribution.503",
+			Predicate: benchttp.TestPredicate("EQ"),
+		}}
+		search := benchttp.CapacitySearch{
+			Runner: runner,
+			Mode:   benchttp.SearchLinear,
+			Target: benchttp.SearchConcurrency,
+			Min:    1,
+			Max:    20,
+			Step:   1,
+		}
+
+		err := search.Validate()
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error type: %T", err)
+		}
+		assertError(t, errInvalid.Errors, `max (20): want <= runner.requests (10) for target "concurrency"`)
+
+		search.Target = benchttp.SearchRate
+		if err := search.Validate(); err != nil {
+			t.Errorf("unexpected error for target rate: %v", err)
+		}
+	})
+
+	t.Run("concurrency with runner rate", func(t *testing.T) {
+		runner := benchttp.DefaultRunner()
+		runner.Rate = 50
+		runner.Tests = []benchttp.TestCase{{
+			Name:      "no server error",
+			Field:     "StatusCodesDistribution.503",
+			Predicate: benchttp.TestPredicate("EQ"),
+		}}
+		search := benchttp.CapacitySearch{
+			Runner: runner,
+			Mode:   benchttp.SearchLinear,
+			Target: benchttp.SearchConcurrency,
+			Min:    1,
+			Max:    5,
+			Step:   1,
+		}
+
+		err := search.Validate()
+
+		var errInvalid *benchttp.InvalidRunnerError
+		if !errors.As(err, &errInvalid) {
+			t.Fatalf("unexpected error type: %T", err)
+		}
+		assertError(t, errInvalid.Errors, `runner.rate (50): want 0 for target "concurrency"`)
+
+		search.Target = benchttp.SearchRate
+		if err := search.Validate(); err != nil {
+			t.Errorf("unexpected error for target rate: %v", err)
+		}
+	})
+}
+
+// helpers
+
+// limitedHandler returns a http.Handler that responds with
+// http.StatusServiceUnavailable when it handles more than
+// maxConcurrent requests at the same time.
+func limitedHandler(maxConcurrent int) http.Handler {
+	var (
+		mu     sync.Mutex
+		active int
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		active++
+		overloaded := active > maxConcurrent
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		if overloaded {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package benchttptest
 import (
 	"bytes"
 	"crypto/tls"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -18,7 +19,7 @@ import (
 // RunnerCmpOptions is the cmp.Options used to compare benchttp.Runner.
 // By default, it ignores unexported fields and includes RequestCmpOptions.
 var RunnerCmpOptions = cmp.Options{
-	cmpopts.IgnoreUnexported(benchttp.Runner{}),
+	cmpopts.IgnoreUnexported(benchttp.Runner{}, benchttp.Check{}, benchttp.Extractor{}),
 	RequestCmpOptions,
 }
 
@@ -71,7 +72,9 @@ func AssertEqualRunners(t *testing.T, x, y benchttp.Runner) {
 // RunnerCmpOptions.
 func EqualRunners(x, y benchttp.Runner) bool {
 	return cmp.Equal(x, y, RunnerCmpOptions) &&
-		compareRequestBody(x.Request, y.Request)
+		compareRequestBody(x.Request, y.Request) &&
+		compareRequestMixBodies(x.RequestMix, y.RequestMix) &&
+		compareScenarioBodies(x.Scenario, y.Scenario)
 }
 
 // DiffRunner returns a string showing the diff between x and y,
@@ -80,11 +83,22 @@ func DiffRunner(x, y benchttp.Runner) string {
 	b := strings.Builder{}
 	b.WriteString(cmp.Diff(x, y, RunnerCmpOptions))
 	if x.Request != nil && y.Request != nil {
-		xbody := nopreadBody(x.Request)
-		ybody := nopreadBody(y.Request)
-		if !bytes.Equal(xbody, ybody) {
-			b.WriteString("Request.Body: ")
-			b.WriteString(cmp.Diff(string(xbody), string(ybody)))
+		writeBodyDiff(&b, "Request.Body", x.Request, y.Request)
+	}
+	if len(x.RequestMix) == len(y.RequestMix) {
+		for i := range x.RequestMix {
+			writeBodyDiff(&b,
+				fmt.Sprintf("RequestMix[%d].Request.Body", i),
+				x.RequestMix[i].Request, y.RequestMix[i].Request,
+			)
+		}
+	}
+	if len(x.Scenario.Steps) == len(y.Scenario.Steps) {
+		for i := range x.Scenario.Steps {
+			writeBodyDiff(&b,
+				fmt.Sprintf("Scenario.Steps[%d].Request.Body", i),
+				x.Scenario.Steps[i].Request, y.Scenario.Steps[i].Request,
+			)
 		}
 	}
 	return b.String()
@@ -113,6 +127,41 @@ func stringifyURL(u *url.URL) string {
 	return u.String()
 }
 
+// writeBodyDiff writes the diff between the bodies of x and y to b,
+// prefixed with name, if they are not equal.
+func writeBodyDiff(b *strings.Builder, name string, x, y *http.Request) {
+	xbody := nopreadBody(x)
+	ybody := nopreadBody(y)
+	if !bytes.Equal(xbody, ybody) {
+		b.WriteString(name + ": ")
+		b.WriteString(cmp.Diff(string(xbody), string(ybody)))
+	}
+}
+
+func compareRequestMixBodies(a, b []benchttp.WeightedRequest) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !compareRequestBody(a[i].Request, b[i].Request) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareScenarioBodies(a, b benchttp.Scenario) bool {
+	if len(a.Steps) != len(b.Steps) {
+		return false
+	}
+	for i := range a.Steps {
+		if !compareRequestBody(a.Steps[i].Request, b.Steps[i].Request) {
+			return false
+		}
+	}
+	return true
+}
+
 func compareRequestBody(a, b *http.Request) bool {
 	ba, bb := nopreadBody(a), nopreadBody(b)
 	return bytes.Equal(ba, bb)
@@ -136,6 +136,22 @@ func TestEqualRunners(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "different request mix bodies",
+			want: false,
+			a: benchttp.Runner{
+				RequestMix: []benchttp.WeightedRequest{{
+					Name:    "a",
+					Request: &http.Request{Body: io.NopCloser(strings.NewReader("hello"))},
+				}},
+			},
+			b: benchttp.Runner{
+				RequestMix: []benchttp.WeightedRequest{{
+					Name:    "a",
+					Request: &http.Request{Body: io.NopCloser(strings.NewReader("world"))},
+				}},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			if benchttptest.EqualRunners(tc.a, tc.b) != tc.want {
@@ -0,0 +1,198 @@
+package configio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/benchttp/engine/benchttp"
+)
+
+// Request body types.
+const (
+	bodyTypeRaw       = "raw"
+	bodyTypeFile      = "file"
+	bodyTypeJSON      = "json"
+	bodyTypeForm      = "form"
+	bodyTypeMultipart = "multipart"
+)
+
+// bodyRepresentation is the raw data model of a request body.
+// The fields used depend on its type:
+//   - raw: Content is sent as is
+//   - file: the content of File is sent
+//   - json: Content is serialized as JSON
+//   - form: Content is a map of fields, url-encoded
+//   - multipart: Fields and Files are sent as multipart/form-data,
+//     Files mapping field names to file paths
+type bodyRepresentation struct {
+	Type    string            `yaml:"type" json:"type"`
+	Content interface{}       `yaml:"content" json:"content"`
+	File    string            `yaml:"file" json:"file"`
+	Fields  map[string]string `yaml:"fields" json:"fields"`
+	Files   map[string]string `yaml:"files" json:"files"`
+}
+
+// resolvePaths makes the relative file paths of the receiver relative
+// to dir instead.
+func (b *bodyRepresentation) resolvePaths(dir string) {
+	b.File = resolvePath(dir, b.File)
+	for field, path := range b.Files {
+		b.Files[field] = resolvePath(dir, path)
+	}
+}
+
+// parseInto sets the body of dst from the receiver, and its Content-Type
+// header if not already set. path is the path of the receiver
+// in the representation, used in error messages.
+func (b bodyRepresentation) parseInto(dst *http.Request, path string) error {
+	provider, contentType, err := b.provider()
+	if err != nil {
+		return fmt.Errorf("configio: %s: %s", path, err)
+	}
+
+	benchttp.SetRequestBody(dst, provider)
+	if contentType != "" {
+		if dst.Header == nil {
+			dst.Header = http.Header{}
+		}
+		if dst.Header.Get("Content-Type") == "" {
+			dst.Header.Set("Content-Type", contentType)
+		}
+	}
+	return nil
+}
+
+// provider returns the provider of the body and its content type,
+// if known. Files are read for each request, other bodies are encoded
+// once. Raw, json and form bodies are templates, file and multipart
+// bodies are sent untouched.
+func (b bodyRepresentation) provider() (benchttp.BodyProvider, string, error) {
+	if b.Type == bodyTypeFile {
+		if b.File == "" {
+			return benchttp.BodyProvider{}, "", fmt.Errorf("file: missing field")
+		}
+		provider, err := benchttp.FileBody(b.File)
+		if err != nil {
+			return benchttp.BodyProvider{}, "", fmt.Errorf("file: %s", err)
+		}
+		return provider, mime.TypeByExtension(filepath.Ext(b.File)), nil
+	}
+
+	content, contentType, err := b.encode()
+	if err != nil {
+		return benchttp.BodyProvider{}, "", err
+	}
+	provider := benchttp.BytesBody(content)
+	provider.Template = b.Type != bodyTypeMultipart
+	return provider, contentType, nil
+}
+
+// encode returns the encoded body and its content type, if known.
+func (b bodyRepresentation) encode() ([]byte, string, error) {
+	switch b.Type {
+	case bodyTypeRaw:
+		s, ok := b.Content.(string)
+		if !ok && b.Content != nil {
+			return nil, "", fmt.Errorf("content: want a string for type %q", b.Type)
+		}
+		return []byte(s), "", nil
+	case bodyTypeJSON:
+		content, err := json.Marshal(b.Content)
+		if err != nil {
+			return nil, "", fmt.Errorf("content: %s", err)
+		}
+		return content, "application/json", nil
+	case bodyTypeForm:
+		values, err := formValues(b.Content)
+		if err != nil {
+			return nil, "", fmt.Errorf("content: %s", err)
+		}
+		return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+	case bodyTypeMultipart:
+		return b.encodeMultipart()
+	default:
+		return nil, "", fmt.Errorf(
+			"type %q: want %q, %q, %q, %q or %q", b.Type,
+			bodyTypeRaw, bodyTypeFile, bodyTypeJSON, bodyTypeForm, bodyTypeMultipart,
+		)
+	}
+}
+
+// encodeMultipart returns the receiver's fields and files encoded
+// as multipart/form-data and the matching content type.
+// Parts are written in the order of their names.
+func (b bodyRepresentation) encodeMultipart() ([]byte, string, error) {
+	buf := bytes.Buffer{}
+	w := multipart.NewWriter(&buf)
+
+	for _, name := range sortedKeys(b.Fields) {
+		if err := w.WriteField(name, b.Fields[name]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, name := range sortedKeys(b.Files) {
+		path := b.Files[name]
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("files.%s: %s", name, err)
+		}
+		part, err := w.CreateFormFile(name, filepath.Base(path))
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// formValues returns content as url.Values. content must be a map
+// of scalar values or lists of scalar values.
+func formValues(content interface{}) (url.Values, error) {
+	values := url.Values{}
+	if content == nil {
+		return values, nil
+	}
+
+	fields, ok := content.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("want a map of fields for type %q", bodyTypeForm)
+	}
+
+	for name, v := range fields {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				values.Add(name, fmt.Sprint(item))
+			}
+		case map[string]interface{}:
+			return nil, fmt.Errorf("%s: want a value or a list of values", name)
+		default:
+			values.Add(name, fmt.Sprint(v))
+		}
+	}
+	return values, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package configio_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/benchttp/engine/configio"
+)
+
+func TestBuilder_DecodeYAML_body(t *testing.T) {
+	const csvPath = "internal/testdata/valid/users.csv"
+
+	t.Run("encode body types", func(t *testing.T) {
+		testcases := []struct {
+			label          string
+			in             string
+			expBody        string
+			expContentType string
+			expTemplate    bool
+		}{
+			{
+				label:       "raw",
+				in:          `{type: raw, content: 'a=b'}`,
+				expBody:     "a=b",
+				expTemplate: true,
+			},
+			{
+				label:          "file",
+				in:             `{type: file, file: ` + csvPath + `}`,
+				expBody:        "userID,apiKey\n1,key-a\n2,key-b\n",
+				expContentType: mime.TypeByExtension(".csv"), // depends on the system
+				expTemplate:    false,
+			},
+			{
+				label:          "json",
+				in:             `{type: json, content: {name: jdoe, tags: [a, b], age: 42}}`,
+				expBody:        `{"age":42,"name":"jdoe","tags":["a","b"]}`,
+				expContentType: "application/json",
+				expTemplate:    true,
+			},
+			{
+				label:          "form",
+				in:             `{type: form, content: {name: j doe, tags: [a, b]}}`,
+				expBody:        "name=j+doe&tags=a&tags=b",
+				expContentType: "application/x-www-form-urlencoded",
+				expTemplate:    true,
+			},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.label, func(t *testing.T) {
+				b := configio.Builder{}
+				in := "request:\n  body: " + tc.in
+				if err := b.DecodeYAML([]byte(in)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				req := b.Runner().Request
+				if got := mustReadBody(t, req.GetBody); got != tc.expBody {
+					t.Errorf("unexpected body:\nexp %q\ngot %q", tc.expBody, got)
+				}
+				if got := req.Header.Get("Content-Type"); got != tc.expContentType {
+					t.Errorf("unexpected content type:\nexp %q\ngot %q", tc.expContentType, got)
+				}
+				if got := isTemplateBody(req.Body); got != tc.expTemplate {
+					t.Errorf("template body: exp %v, got %v", tc.expTemplate, got)
+				}
+			})
+		}
+	})
+
+	t.Run("encode multipart body", func(t *testing.T) {
+		in := `
+request:
+  body:
+    type: multipart
+    fields: {description: users}
+    files: {upload: ` + csvPath + `}`
+
+		b := configio.Builder{}
+		if err := b.DecodeYAML([]byte(in)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req := b.Runner().Request
+
+		mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/form-data" {
+			t.Fatalf("unexpected content type: %q", req.Header.Get("Content-Type"))
+		}
+
+		form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatalf("unexpected error reading form: %v", err)
+		}
+		if got := form.Value["description"]; len(got) != 1 || got[0] != "users" {
+			t.Errorf("unexpected field description: %v", got)
+		}
+		if got := form.File["upload"]; len(got) != 1 || got[0].Filename != "users.csv" {
+			t.Errorf("unexpected file upload: %v", got)
+		}
+		if isTemplateBody(req.Body) {
+			t.Error("exp multipart body not to be a template")
+		}
+	})
+
+	t.Run("keep explicit content type", func(t *testing.T) {
+		in := `
+request:
+  header:
+    Content-Type: [application/vnd.api+json]
+  body:
+    type: json
+    content: {}`
+
+		b := configio.Builder{}
+		if err := b.DecodeYAML([]byte(in)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		exp := "application/vnd.api+json"
+		if got := b.Runner().Request.Header.Get("Content-Type"); got != exp {
+			t.Errorf("unexpected content type:\nexp %q\ngot %q", exp, got)
+		}
+	})
+
+	t.Run("return errors for invalid bodies", func(t *testing.T) {
+		testcases := []struct {
+			label  string
+			in     string
+			expErr string
+		}{
+			{
+				label:  "unknown type",
+				in:     `{type: xml}`,
+				expErr: `request.body: type "xml": want "raw", "file", "json", "form" or "multipart"`,
+			},
+			{
+				label:  "raw non-string content",
+				in:     `{type: raw, content: {a: b}}`,
+				expErr: `request.body: content: want a string for type "raw"`,
+			},
+			{
+				label:  "missing file",
+				in:     `{type: file, file: does-not-exist.txt}`,
+				expErr: "request.body: file: stat does-not-exist.txt",
+			},
+			{
+				label:  "form nested content",
+				in:     `{type: form, content: {a: {b: c}}}`,
+				expErr: "request.body: content: a: want a value or a list of values",
+			},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.label, func(t *testing.T) {
+				b := configio.Builder{}
+				err := b.DecodeYAML([]byte("request:\n  body: " + tc.in))
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("unexpected error:\nexp %s\ngot %v", tc.expErr, err)
+				}
+			})
+		}
+	})
+}
+
+func mustReadBody(t *testing.T, getBody func() (io.ReadCloser, error)) string {
+	t.Helper()
+	if getBody == nil {
+		t.Fatal("exp non-nil GetBody")
+	}
+	body, err := getBody()
+	if err != nil {
+		t.Fatalf("unexpected GetBody error: %v", err)
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	return string(b)
+}
+
+// isTemplateBody returns true if body is rendered as a template.
+func isTemplateBody(body io.ReadCloser) bool {
+	b, ok := body.(interface{ IsTemplate() bool })
+	return ok && b.IsTemplate()
+}
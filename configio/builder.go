@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/benchttp/engine/benchttp"
@@ -114,15 +115,50 @@ func (b *Builder) SetRequestHeaderFunc(f func(prev http.Header) http.Header) {
 }
 
 // SetRequestBody adds a mutation that sets a runner's request body to v.
+// v is read and closed once, when the mutation is first applied,
+// so that the body can be sent multiple times.
 func (b *Builder) SetRequestBody(v io.ReadCloser) {
+	var (
+		once     sync.Once
+		provider benchttp.BodyProvider
+	)
 	b.append(func(runner *benchttp.Runner) {
-		if runner.Request == nil {
-			runner.Request = &http.Request{}
-		}
-		runner.Request.Body = v
+		once.Do(func() {
+			provider = readBodyProvider(v)
+		})
+		setRunnerRequestBody(runner, provider)
+	})
+}
+
+// SetRequestBodyProvider adds a mutation that sets a runner's request body
+// to the body provided by v.
+func (b *Builder) SetRequestBodyProvider(v benchttp.BodyProvider) {
+	b.append(func(runner *benchttp.Runner) {
+		setRunnerRequestBody(runner, v)
 	})
 }
 
+func setRunnerRequestBody(runner *benchttp.Runner, p benchttp.BodyProvider) {
+	if runner.Request == nil {
+		runner.Request = &http.Request{}
+	}
+	benchttp.SetRequestBody(runner.Request, p)
+}
+
+// readBodyProvider reads and closes v, and returns a BodyProvider
+// of its content. If reading v fails, the returned BodyProvider
+// returns the error when opened.
+func readBodyProvider(v io.ReadCloser) benchttp.BodyProvider {
+	defer v.Close()
+	content, err := io.ReadAll(v)
+	if err != nil {
+		return benchttp.FuncBody(func() (io.ReadCloser, error) {
+			return nil, err
+		})
+	}
+	return benchttp.BytesBody(content)
+}
+
 // SetRequests adds a mutation that sets a runner's
 // Requests field to v.
 func (b *Builder) SetRequests(v int) {
@@ -147,6 +183,22 @@ func (b *Builder) SetInterval(v time.Duration) {
 	})
 }
 
+// SetRate adds a mutation that sets a runner's
+// Rate field to v.
+func (b *Builder) SetRate(v int) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Rate = v
+	})
+}
+
+// SetProtocol adds a mutation that sets a runner's
+// Protocol field to v.
+func (b *Builder) SetProtocol(v benchttp.Protocol) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Protocol = v
+	})
+}
+
 // SetRequestTimeout adds a mutation that sets a runner's
 // RequestTimeout field to v.
 func (b *Builder) SetRequestTimeout(v time.Duration) {
@@ -163,6 +215,142 @@ func (b *Builder) SetGlobalTimeout(v time.Duration) {
 	})
 }
 
+// SetDuration adds a mutation that sets a runner's
+// Duration field to v.
+func (b *Builder) SetDuration(v time.Duration) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Duration = v
+	})
+}
+
+// SetWarmup adds a mutation that sets a runner's
+// Warmup field to v.
+func (b *Builder) SetWarmup(v time.Duration) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Warmup = v
+	})
+}
+
+// SetWarmupRequests adds a mutation that sets a runner's
+// WarmupRequests field to v.
+func (b *Builder) SetWarmupRequests(v int) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.WarmupRequests = v
+	})
+}
+
+// SetSeed adds a mutation that sets a runner's
+// Seed field to v.
+func (b *Builder) SetSeed(v int64) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Seed = v
+	})
+}
+
+// SetTimeSeriesWindow adds a mutation that sets a runner's
+// TimeSeriesWindow field to v.
+func (b *Builder) SetTimeSeriesWindow(v time.Duration) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.TimeSeriesWindow = v
+	})
+}
+
+// SetPercentiles adds a mutation that sets a runner's
+// Percentiles field to v.
+func (b *Builder) SetPercentiles(v []float64) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Percentiles = v
+	})
+}
+
+// SetStages adds a mutation that sets a runner's
+// Stages field to v.
+func (b *Builder) SetStages(v []benchttp.Stage) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Stages = v
+	})
+}
+
+// SetAbortConditions adds a mutation that sets a runner's
+// AbortConditions field to v.
+func (b *Builder) SetAbortConditions(v []benchttp.AbortCondition) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.AbortConditions = v
+	})
+}
+
+// SetScenario adds a mutation that sets a runner's
+// Scenario field to v.
+func (b *Builder) SetScenario(v benchttp.Scenario) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Scenario = v
+	})
+}
+
+// SetFeeder adds a mutation that sets a runner's
+// Feeder field to v.
+func (b *Builder) SetFeeder(v benchttp.Feeder) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Feeder = v
+	})
+}
+
+// SetAuth adds a mutation that sets a runner's
+// Auth field to v.
+func (b *Builder) SetAuth(v benchttp.Auth) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Auth = v
+	})
+}
+
+// SetSigner adds a mutation that sets a runner's
+// Signer field to v.
+func (b *Builder) SetSigner(v benchttp.Signer) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Signer = v
+	})
+}
+
+// SetTLSConfig adds a mutation that sets a runner's
+// TLSConfig field to v.
+func (b *Builder) SetTLSConfig(v benchttp.TLSConfig) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.TLSConfig = v
+	})
+}
+
+// SetDialConfig adds a mutation that sets a runner's
+// DialConfig field to v.
+func (b *Builder) SetDialConfig(v benchttp.DialConfig) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.DialConfig = v
+	})
+}
+
+// SetProxy adds a mutation that sets a runner's
+// Proxy field to v.
+func (b *Builder) SetProxy(v benchttp.Proxy) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Proxy = v
+	})
+}
+
+// SetChecks adds a mutation that sets a runner's
+// Checks field to v.
+func (b *Builder) SetChecks(v []benchttp.Check) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.Checks = v
+	})
+}
+
+// SetVirtualUsers adds a mutation that sets a runner's
+// VirtualUsers field to v.
+func (b *Builder) SetVirtualUsers(v benchttp.VirtualUsers) {
+	b.append(func(runner *benchttp.Runner) {
+		runner.VirtualUsers = v
+	})
+}
+
 // SetTests adds a mutation that sets a runner's
 // Tests field to v.
 func (b *Builder) SetTests(v []benchttp.TestCase) {
@@ -1,6 +1,7 @@
 package configio_test
 
 import (
+	"crypto/tls"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -45,11 +46,26 @@ func TestBuilder_WriteYAML(t *testing.T) {
 func TestBuilder_Set(t *testing.T) {
 	t.Run("basic fields", func(t *testing.T) {
 		want := benchttp.Runner{
-			Requests:       5,
-			Concurrency:    2,
-			Interval:       10 * time.Millisecond,
-			RequestTimeout: 1 * time.Second,
-			GlobalTimeout:  10 * time.Second,
+			Requests:         5,
+			Concurrency:      2,
+			Interval:         10 * time.Millisecond,
+			Rate:             50,
+			Protocol:         benchttp.ProtocolH2,
+			RequestTimeout:   1 * time.Second,
+			GlobalTimeout:    10 * time.Second,
+			Duration:         8 * time.Second,
+			Warmup:           1 * time.Second,
+			WarmupRequests:   2,
+			Seed:             42,
+			TimeSeriesWindow: 5 * time.Second,
+			Percentiles:      []float64{50, 99.9},
+			Stages: []benchttp.Stage{
+				{Duration: 2 * time.Second, Target: 10},
+				{Duration: 5 * time.Second, Target: 10},
+			},
+			AbortConditions: []benchttp.AbortCondition{
+				{FailureRate: 0.2, WindowRequests: 100},
+			},
 		}
 
 		b := configio.Builder{}
@@ -57,8 +73,100 @@ func TestBuilder_Set(t *testing.T) {
 		b.SetConcurrency(-1)
 		b.SetConcurrency(want.Concurrency)
 		b.SetInterval(want.Interval)
+		b.SetRate(want.Rate)
+		b.SetProtocol(want.Protocol)
 		b.SetRequestTimeout(want.RequestTimeout)
 		b.SetGlobalTimeout(want.GlobalTimeout)
+		b.SetDuration(want.Duration)
+		b.SetWarmup(want.Warmup)
+		b.SetWarmupRequests(want.WarmupRequests)
+		b.SetSeed(want.Seed)
+		b.SetTimeSeriesWindow(want.TimeSeriesWindow)
+		b.SetPercentiles(want.Percentiles)
+		b.SetStages(want.Stages)
+		b.SetAbortConditions(want.AbortConditions)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("scenario", func(t *testing.T) {
+		want := benchttp.Runner{
+			Scenario: benchttp.Scenario{Steps: []benchttp.Step{{
+				Name:    "login",
+				Request: httptest.NewRequest("POST", "https://example.com/login", nil),
+				Extractors: []benchttp.Extractor{
+					{Var: "token", Type: benchttp.ExtractJSON, Expression: "/token"},
+				},
+			}}},
+		}
+
+		b := configio.Builder{}
+		b.SetScenario(want.Scenario)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("feeder", func(t *testing.T) {
+		want := benchttp.Runner{
+			Feeder: benchttp.Feeder{
+				Rows: []map[string]string{{"userID": "1"}, {"userID": "2"}},
+				Mode: benchttp.FeedPerWorker,
+			},
+		}
+
+		b := configio.Builder{}
+		b.SetFeeder(want.Feeder)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		want := benchttp.Runner{
+			Auth: benchttp.Auth{Type: benchttp.AuthBasic, Username: "jdoe", Password: "secret"},
+		}
+
+		b := configio.Builder{}
+		b.SetAuth(want.Auth)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("signer", func(t *testing.T) {
+		want := benchttp.Runner{
+			Signer: benchttp.HMACSigner{Secret: "secret"},
+		}
+
+		b := configio.Builder{}
+		b.SetSigner(want.Signer)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("tls config", func(t *testing.T) {
+		want := benchttp.Runner{
+			TLSConfig: benchttp.TLSConfig{
+				CAFile:     "ca.pem",
+				ServerName: "api.internal",
+				MinVersion: tls.VersionTLS12,
+			},
+		}
+
+		b := configio.Builder{}
+		b.SetTLSConfig(want.TLSConfig)
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("virtual users", func(t *testing.T) {
+		want := benchttp.Runner{
+			VirtualUsers: benchttp.VirtualUsers{
+				Cookies:    true,
+				Connection: benchttp.ConnectionPerRequest,
+			},
+		}
+
+		b := configio.Builder{}
+		b.SetVirtualUsers(want.VirtualUsers)
 
 		benchttptest.AssertEqualRunners(t, want, b.Runner())
 	})
@@ -0,0 +1,102 @@
+package configio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// readFeederFile reads the rows of the feeder file at path, according
+// to its extension:
+//   - .csv: the first record is the header naming the columns,
+//     each following record is a row
+//   - .jsonl, .ndjson: each line is a JSON object, whose keys are
+//     the columns; string values are kept as is, other values
+//     are JSON-encoded
+func readFeederFile(path string) ([]map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	switch ext := filepath.Ext(path); ext {
+	case ".csv":
+		rows, err = parseCSVRows(b)
+	case ".jsonl", ".ndjson":
+		rows, err = parseJSONLRows(b)
+	default:
+		return nil, fmt.Errorf("%s: unsupported extension %q: want .csv, .jsonl or .ndjson", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: no rows", path)
+	}
+	return rows, nil
+}
+
+func parseCSVRows(b []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i, column := range header {
+		if column == "" {
+			return nil, fmt.Errorf("header: column %d: empty name", i)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONLRows(b []byte) ([]map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var rows []map[string]string
+	for i := 0; ; i++ {
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %s", i, err)
+		}
+
+		row := make(map[string]string, len(object))
+		for key, v := range object {
+			if s, ok := v.(string); ok {
+				row[key] = s
+				continue
+			}
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s", i, err)
+			}
+			row[key] = string(encoded)
+		}
+		rows = append(rows, row)
+	}
+}
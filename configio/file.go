@@ -92,6 +92,8 @@ func (f *file) decode() (err error) {
 		return errorutil.WithDetails(ErrFileParse, f.path, err)
 	}
 
+	f.repr.resolvePaths(filepath.Dir(f.path))
+
 	return nil
 }
 
@@ -1,8 +1,11 @@
 package configio_test
 
 import (
+	"bytes"
 	"errors"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -90,6 +93,11 @@ func TestUnmarshalFile(t *testing.T) {
 				file:   testdata.InvalidFieldsJSON(),
 				expErr: configio.ErrFileParse,
 			},
+			{
+				label:  "feeder file unsupported extension",
+				file:   testdata.InvalidFeeder(),
+				expErr: configio.ErrFileParse,
+			},
 			{
 				label:  "self reference",
 				file:   testdata.InvalidExtendsSelf(),
@@ -181,6 +189,48 @@ func TestUnmarshalFile(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("extend config files with a request mix", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile := func(name, content string) string {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}
+
+		writeFile("parent.yml", `
+request:
+  - name: home
+    weight: 1
+    url: http://localhost:3000
+`)
+		keepMix := writeFile("keep-mix.yml", `
+extends: ./parent.yml
+runner:
+  requests: 10
+`)
+		addRequest := writeFile("add-request.yml", `
+extends: ./parent.yml
+request:
+  url: http://localhost:3000/child
+`)
+
+		runner := benchttp.DefaultRunner()
+		mustAssertNilError(t, configio.UnmarshalFile(keepMix, &runner))
+		if runner.Request != nil || len(runner.RequestMix) != 1 {
+			t.Errorf("exp parent request mix only, got %v and %v", runner.Request, runner.RequestMix)
+		}
+		mustAssertNilError(t, runner.Validate())
+
+		runner = benchttp.DefaultRunner()
+		mustAssertNilError(t, configio.UnmarshalFile(addRequest, &runner))
+		var errInvalid *benchttp.InvalidRunnerError
+		if err := runner.Validate(); !errors.As(err, &errInvalid) {
+			t.Fatalf("exp *benchttp.InvalidRunnerError, got %v", err)
+		}
+	})
 }
 
 // helpers
@@ -198,3 +248,40 @@ func assertStaticError(t *testing.T, exp, got error) {
 		t.Errorf("unexpected error:\nexp %v\ngot %v", exp, got)
 	}
 }
+
+func TestUnmarshalFile_examples(t *testing.T) {
+	var paths []string
+	for _, ext := range []string{"yml", "yaml", "json"} {
+		matches, err := filepath.Glob("../examples/config/*." + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no example config file found")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			runner := benchttp.DefaultRunner()
+			if filepath.Base(path) == "default.yml" {
+				// default.yml lists the default values, including an empty
+				// url that is required to run: set one to validate the rest.
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content = bytes.Replace(content, []byte(`url: ""`), []byte("url: http://localhost:8080"), 1)
+				if err := configio.UnmarshalYAML(content, &runner); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if err := configio.UnmarshalFile(path, &runner); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := runner.Validate(); err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
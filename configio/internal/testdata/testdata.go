@@ -2,6 +2,7 @@ package testdata
 
 import (
 	"bytes"
+	"crypto/tls"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -59,6 +60,10 @@ func InvalidExtension() ConfigFile {
 	return invalidConfig("extension.yams")
 }
 
+func InvalidFeeder() ConfigFile {
+	return invalidConfig("feeder.yml")
+}
+
 func InvalidExtendsCircular() ConfigFile {
 	return invalidConfig("extends/circular-0.yml")
 }
@@ -122,16 +127,99 @@ func fullRunner() benchttp.Runner {
 	return benchttp.Runner{
 		Request: request,
 
-		Requests:       100,
-		Concurrency:    1,
-		Interval:       50 * time.Millisecond,
-		RequestTimeout: 2 * time.Second,
-		GlobalTimeout:  60 * time.Second,
+		Requests:         100,
+		Concurrency:      1,
+		Interval:         50 * time.Millisecond,
+		RequestTimeout:   2 * time.Second,
+		GlobalTimeout:    60 * time.Second,
+		Duration:         30 * time.Second,
+		Warmup:           5 * time.Second,
+		WarmupRequests:   10,
+		Protocol:         benchttp.ProtocolHTTP1,
+		Seed:             42,
+		TimeSeriesWindow: 5 * time.Second,
+		Percentiles:      []float64{50, 95, 99.9},
+		Stages: []benchttp.Stage{
+			{Duration: 10 * time.Second, Target: 5},
+			{Duration: 20 * time.Second, Target: 5},
+		},
+		AbortConditions: []benchttp.AbortCondition{
+			{FailureRate: 0.2, WindowRequests: 100},
+			{Latency: 5 * time.Second, Percentile: 95, WindowDuration: 10 * time.Second},
+		},
+
+		Scenario: benchttp.Scenario{Steps: []benchttp.Step{
+			{
+				Name:    "login",
+				Request: httptest.NewRequest("POST", "http://localhost:3000/login", nil),
+				Extractors: []benchttp.Extractor{
+					{Var: "token", Type: "json", Expression: "/token"},
+				},
+			},
+			{
+				Name: "orders",
+				Request: func() *http.Request {
+					req := httptest.NewRequest("GET", "http://localhost:3000/orders", nil)
+					req.Header = http.Header{"Authorization": []string{"Bearer {{.token}}"}}
+					return req
+				}(),
+			},
+		}},
+
+		Feeder: benchttp.Feeder{
+			Rows: []map[string]string{
+				{"userID": "1", "apiKey": "key-a"},
+				{"userID": "2", "apiKey": "key-b"},
+			},
+			Mode: benchttp.FeedPerWorker,
+		},
+
+		Auth: benchttp.Auth{
+			Type:         benchttp.AuthOAuth2,
+			TokenURL:     "http://localhost:3000/token",
+			ClientID:     "benchttp",
+			ClientSecret: "secret",
+			Scopes:       []string{"read", "write"},
+		},
+
+		Signer: benchttp.HMACSigner{
+			Secret: "signing-secret",
+			Header: "X-Benchttp-Signature",
+		},
+
+		TLSConfig: benchttp.TLSConfig{
+			ServerName:   "localhost",
+			MinVersion:   tls.VersionTLS12,
+			MaxVersion:   tls.VersionTLS13,
+			CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		},
+
+		DialConfig: benchttp.DialConfig{
+			Resolve:  map[string]string{"example.com:443": "127.0.0.1"},
+			DNSCache: true,
+		},
+
+		Proxy: benchttp.Proxy{
+			URL:      "http://localhost:3128",
+			Username: "proxy-user",
+			Password: "proxy-password",
+			NoProxy:  []string{".internal", "10.0.0.0/8"},
+		},
+
+		Checks: []benchttp.Check{
+			{Type: benchttp.CheckStatus, Expression: "2xx"},
+			{Name: "status ok", Type: benchttp.CheckJSONEquals, Expression: "/status", Value: "ok"},
+		},
+
+		VirtualUsers: benchttp.VirtualUsers{
+			Cookies:    true,
+			Connection: benchttp.ConnectionNoKeepAlive,
+		},
 
 		Tests: []benchttp.TestCase{
 			{
-				Name:      "maximum response time",
-				Field:     "ResponseTimes.Max",
+				Name:      "p99.9 response time",
+				Field:     "ResponseTimes.P99_9",
 				Predicate: "LTE",
 				Target:    120 * time.Millisecond,
 			},
@@ -141,6 +229,12 @@ func fullRunner() benchttp.Runner {
 				Predicate: "EQ",
 				Target:    0,
 			},
+			{
+				Name:      "minimum throughput",
+				Field:     "TimeSeries.*.Throughput",
+				Predicate: "GTE",
+				Target:    10.0,
+			},
 		},
 	}
 }
@@ -160,5 +254,10 @@ func extendedRunner() benchttp.Runner {
 		Request: httptest.NewRequest("PUT", "http://localhost:3000/child", nil),
 		// parent kept value
 		GlobalTimeout: 42 * time.Second,
+		// parent file resolved relatively to the parent
+		Feeder: benchttp.Feeder{Rows: []map[string]string{
+			{"userID": "1", "tags": `["a"]`},
+			{"userID": "2", "tags": `[]`},
+		}},
 	}
 }
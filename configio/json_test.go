@@ -42,6 +42,33 @@ func TestMarshalJSON(t *testing.T) {
 			isValidRunner: func(_, _ benchttp.Runner) bool { return true },
 			expError:      errors.New(`wrong type for field runner.concurrency: want int, got string`),
 		},
+		{
+			name: "returns error if input request mix has bad keys",
+			input: object{
+				"request": []object{{"name": "a", "badkey": "marcel-patulacci"}},
+			}.json(),
+			isValidRunner: func(_, _ benchttp.Runner) bool { return true },
+			expError:      errors.New(`invalid field ("badkey"): does not exist`),
+		},
+		{
+			name: "unmarshals request mix",
+			input: object{
+				"request": []object{
+					{"name": "login", "weight": 1, "method": "POST", "url": testURL + "/login"},
+					{"name": "browse", "weight": 9, "url": testURL},
+				},
+			}.json(),
+			isValidRunner: func(_, got benchttp.Runner) bool {
+				mix := got.RequestMix
+				return len(mix) == 2 &&
+					mix[0].Name == "login" && mix[0].Weight == 1 &&
+					mix[0].Request.Method == "POST" &&
+					mix[0].Request.URL.String() == testURL+"/login" &&
+					mix[1].Name == "browse" && mix[1].Weight == 9 &&
+					mix[1].Request.URL.String() == testURL
+			},
+			expError: nil,
+		},
 		{
 			name:  "unmarshals JSON config and merges it with base runner",
 			input: baseInput.json(),
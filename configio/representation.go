@@ -2,11 +2,14 @@ package configio
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"time"
 
@@ -22,25 +25,102 @@ import (
 type representation struct {
 	Extends *string `yaml:"extends" json:"extends"`
 
-	Request struct {
-		Method      *string             `yaml:"method" json:"method"`
-		URL         *string             `yaml:"url" json:"url"`
-		QueryParams map[string]string   `yaml:"queryParams" json:"queryParams"`
-		Header      map[string][]string `yaml:"header" json:"header"`
-		Body        *struct {
-			Type    string `yaml:"type" json:"type"`
-			Content string `yaml:"content" json:"content"`
-		} `yaml:"body" json:"body"`
-	} `yaml:"request" json:"request"`
+	Request requestSection `yaml:"request" json:"request"`
 
 	Runner struct {
-		Requests       *int    `yaml:"requests" json:"requests"`
-		Concurrency    *int    `yaml:"concurrency" json:"concurrency"`
-		Interval       *string `yaml:"interval" json:"interval"`
-		RequestTimeout *string `yaml:"requestTimeout" json:"requestTimeout"`
-		GlobalTimeout  *string `yaml:"globalTimeout" json:"globalTimeout"`
+		Requests         *int      `yaml:"requests" json:"requests"`
+		Concurrency      *int      `yaml:"concurrency" json:"concurrency"`
+		Interval         *string   `yaml:"interval" json:"interval"`
+		Rate             *int      `yaml:"rate" json:"rate"`
+		Protocol         *string   `yaml:"protocol" json:"protocol"`
+		RequestTimeout   *string   `yaml:"requestTimeout" json:"requestTimeout"`
+		GlobalTimeout    *string   `yaml:"globalTimeout" json:"globalTimeout"`
+		Duration         *string   `yaml:"duration" json:"duration"`
+		Warmup           *string   `yaml:"warmup" json:"warmup"`
+		WarmupRequests   *int      `yaml:"warmupRequests" json:"warmupRequests"`
+		Seed             *int64    `yaml:"seed" json:"seed"`
+		TimeSeriesWindow *string   `yaml:"timeSeriesWindow" json:"timeSeriesWindow"`
+		Percentiles      []float64 `yaml:"percentiles" json:"percentiles"`
+		Stages           []struct {
+			Duration *string `yaml:"duration" json:"duration"`
+			Target   *int    `yaml:"target" json:"target"`
+		} `yaml:"stages" json:"stages"`
+		AbortConditions []struct {
+			FailureRate    *float64 `yaml:"failureRate" json:"failureRate"`
+			Latency        *string  `yaml:"latency" json:"latency"`
+			Percentile     *int     `yaml:"percentile" json:"percentile"`
+			WindowRequests *int     `yaml:"windowRequests" json:"windowRequests"`
+			WindowDuration *string  `yaml:"windowDuration" json:"windowDuration"`
+		} `yaml:"abortConditions" json:"abortConditions"`
 	} `yaml:"runner" json:"runner"`
 
+	Scenario struct {
+		Steps []struct {
+			Name       *string               `yaml:"name" json:"name"`
+			Request    requestRepresentation `yaml:"request" json:"request"`
+			Extractors []struct {
+				Var        *string `yaml:"var" json:"var"`
+				Type       *string `yaml:"type" json:"type"`
+				Expression *string `yaml:"expression" json:"expression"`
+			} `yaml:"extractors" json:"extractors"`
+		} `yaml:"steps" json:"steps"`
+	} `yaml:"scenario" json:"scenario"`
+
+	Feeder struct {
+		File *string `yaml:"file" json:"file"`
+		Mode *string `yaml:"mode" json:"mode"`
+	} `yaml:"feeder" json:"feeder"`
+
+	Auth struct {
+		Type         *string  `yaml:"type" json:"type"`
+		Username     *string  `yaml:"username" json:"username"`
+		Password     *string  `yaml:"password" json:"password"`
+		Token        *string  `yaml:"token" json:"token"`
+		TokenURL     *string  `yaml:"tokenURL" json:"tokenURL"`
+		ClientID     *string  `yaml:"clientID" json:"clientID"`
+		ClientSecret *string  `yaml:"clientSecret" json:"clientSecret"`
+		Scopes       []string `yaml:"scopes" json:"scopes"`
+	} `yaml:"auth" json:"auth"`
+
+	Signer struct {
+		Type            *string `yaml:"type" json:"type"`
+		Secret          *string `yaml:"secret" json:"secret"`
+		Header          *string `yaml:"header" json:"header"`
+		TimestampHeader *string `yaml:"timestampHeader" json:"timestampHeader"`
+		AccessKeyID     *string `yaml:"accessKeyID" json:"accessKeyID"`
+		SecretAccessKey *string `yaml:"secretAccessKey" json:"secretAccessKey"`
+		SessionToken    *string `yaml:"sessionToken" json:"sessionToken"`
+		Region          *string `yaml:"region" json:"region"`
+		Service         *string `yaml:"service" json:"service"`
+	} `yaml:"signer" json:"signer"`
+
+	TLS tlsRepresentation `yaml:"tls" json:"tls"`
+
+	Dial struct {
+		Resolve    map[string]string `yaml:"resolve" json:"resolve"`
+		DNSCache   *bool             `yaml:"dnsCache" json:"dnsCache"`
+		UnixSocket *string           `yaml:"unixSocket" json:"unixSocket"`
+	} `yaml:"dial" json:"dial"`
+
+	Proxy struct {
+		URL      *string  `yaml:"url" json:"url"`
+		Username *string  `yaml:"username" json:"username"`
+		Password *string  `yaml:"password" json:"password"`
+		NoProxy  []string `yaml:"noProxy" json:"noProxy"`
+	} `yaml:"proxy" json:"proxy"`
+
+	VirtualUsers struct {
+		Cookies    *bool   `yaml:"cookies" json:"cookies"`
+		Connection *string `yaml:"connection" json:"connection"`
+	} `yaml:"virtualUsers" json:"virtualUsers"`
+
+	Checks []struct {
+		Name       *string `yaml:"name" json:"name"`
+		Type       *string `yaml:"type" json:"type"`
+		Expression *string `yaml:"expression" json:"expression"`
+		Value      *string `yaml:"value" json:"value"`
+	} `yaml:"checks" json:"checks"`
+
 	Tests []struct {
 		Name      *string     `yaml:"name" json:"name"`
 		Field     *string     `yaml:"field" json:"field"`
@@ -49,6 +129,54 @@ type representation struct {
 	} `yaml:"tests" json:"tests"`
 }
 
+// requestRepresentation is the raw data model of a single request.
+type requestRepresentation struct {
+	Method      *string             `yaml:"method" json:"method"`
+	URL         *string             `yaml:"url" json:"url"`
+	QueryParams map[string]string   `yaml:"queryParams" json:"queryParams"`
+	Header      map[string][]string `yaml:"header" json:"header"`
+	Body        *bodyRepresentation `yaml:"body" json:"body"`
+}
+
+// weightedRequestRepresentation is the raw data model of a request
+// of a mix.
+type weightedRequestRepresentation struct {
+	Name                  *string `yaml:"name" json:"name"`
+	Weight                *int    `yaml:"weight" json:"weight"`
+	requestRepresentation `yaml:",inline"`
+}
+
+// requestSection is the raw data model of the request section,
+// that is either a single request (object) or a mix of weighted
+// requests (array).
+type requestSection struct {
+	requestRepresentation
+	Mix []weightedRequestRepresentation
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *requestSection) UnmarshalJSON(in []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(in))
+	decoder.DisallowUnknownFields()
+	if bytes.HasPrefix(bytes.TrimSpace(in), []byte("[")) {
+		return decoder.Decode(&s.Mix)
+	}
+	return decoder.Decode(&s.requestRepresentation)
+}
+
+// UnmarshalYAML implements the legacy yaml.Unmarshaler, so that the
+// calling decoder options (e.g. KnownFields) also apply to the section.
+func (s *requestSection) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	if _, isSequence := raw.([]interface{}); isSequence {
+		return unmarshal(&s.Mix)
+	}
+	return unmarshal(&s.requestRepresentation)
+}
+
 func (repr representation) validate() error {
 	return repr.parseAndMutate(&benchttp.Runner{})
 }
@@ -63,41 +191,142 @@ func (repr representation) parseAndMutate(dst *benchttp.Runner) error {
 	if err := repr.parseRunnerInto(dst); err != nil {
 		return err
 	}
+	if err := repr.parseScenarioInto(dst); err != nil {
+		return err
+	}
+	if err := repr.parseFeederInto(dst); err != nil {
+		return err
+	}
+	repr.parseAuthInto(dst)
+	if err := repr.parseSignerInto(dst); err != nil {
+		return err
+	}
+	if err := repr.TLS.parseInto(&dst.TLSConfig); err != nil {
+		return err
+	}
+	repr.parseDialInto(dst)
+	repr.parseProxyInto(dst)
+	if err := repr.parseChecksInto(dst); err != nil {
+		return err
+	}
+	repr.parseVirtualUsersInto(dst)
 	return repr.parseTestsInto(dst)
 }
 
+// resolvePaths makes the relative file paths of the receiver relative
+// to dir instead, e.g. the directory of the config file referencing them.
+func (repr *representation) resolvePaths(dir string) {
+	if file := repr.Feeder.File; file != nil {
+		resolved := resolvePath(dir, *file)
+		repr.Feeder.File = &resolved
+	}
+	for _, body := range repr.bodies() {
+		body.resolvePaths(dir)
+	}
+	repr.TLS.resolvePaths(dir)
+}
+
+// bodies returns the non-nil request bodies of the receiver.
+func (repr representation) bodies() []*bodyRepresentation {
+	bodies := []*bodyRepresentation{}
+	appendBody := func(r requestRepresentation) {
+		if r.Body != nil {
+			bodies = append(bodies, r.Body)
+		}
+	}
+
+	appendBody(repr.Request.requestRepresentation)
+	for _, wr := range repr.Request.Mix {
+		appendBody(wr.requestRepresentation)
+	}
+	for _, step := range repr.Scenario.Steps {
+		appendBody(step.Request)
+	}
+	return bodies
+}
+
 func (repr representation) parseRequestInto(dst *benchttp.Runner) error {
+	if len(repr.Request.Mix) > 0 {
+		return repr.parseRequestMixInto(dst)
+	}
+
+	// An empty section must not add a single request to a mix
+	// set by an extended config.
+	if repr.Request.requestRepresentation.isEmpty() {
+		return nil
+	}
+
 	if dst.Request == nil {
 		dst.Request = &http.Request{}
 	}
 
-	if method := repr.Request.Method; method != nil {
-		dst.Request.Method = *method
+	return repr.Request.parseInto(dst.Request, "request")
+}
+
+func (repr representation) parseRequestMixInto(dst *benchttp.Runner) error {
+	reprMix := repr.Request.Mix
+
+	mix := make([]benchttp.WeightedRequest, len(reprMix))
+	for i, wr := range reprMix {
+		fieldPath := func(requestField string) string {
+			return fmt.Sprintf("request[%d].%s", i, requestField)
+		}
+
+		if err := requireConfigFields(map[string]interface{}{
+			fieldPath("name"):   wr.Name,
+			fieldPath("weight"): wr.Weight,
+		}); err != nil {
+			return err
+		}
+
+		req := &http.Request{}
+		if err := wr.parseInto(req, fmt.Sprintf("request[%d]", i)); err != nil {
+			return err
+		}
+
+		mix[i] = benchttp.WeightedRequest{
+			Name:    *wr.Name,
+			Weight:  *wr.Weight,
+			Request: req,
+		}
 	}
 
-	if rawURL := repr.Request.URL; rawURL != nil {
-		parsedURL, err := parseAndBuildURL(*rawURL, repr.Request.QueryParams)
+	dst.RequestMix = mix
+	return nil
+}
+
+// isEmpty returns true if none of the fields of r is set.
+func (r requestRepresentation) isEmpty() bool {
+	return r.Method == nil && r.URL == nil && len(r.QueryParams) == 0 &&
+		len(r.Header) == 0 && r.Body == nil
+}
+
+// parseInto stores any non-nil field value of r into the corresponding
+// field of dst. path is the path of r in the representation, used
+// in error messages.
+func (r requestRepresentation) parseInto(dst *http.Request, path string) error {
+	if method := r.Method; method != nil {
+		dst.Method = *method
+	}
+
+	if rawURL := r.URL; rawURL != nil {
+		parsedURL, err := parseAndBuildURL(*rawURL, r.QueryParams)
 		if err != nil {
 			return fmt.Errorf(`configio: invalid url: %q`, *rawURL)
 		}
-		dst.Request.URL = parsedURL
+		dst.URL = parsedURL
 	}
 
-	if header := repr.Request.Header; len(header) != 0 {
+	if header := r.Header; len(header) != 0 {
 		httpHeader := http.Header{}
 		for key, val := range header {
 			httpHeader[key] = val
 		}
-		dst.Request.Header = httpHeader
+		dst.Header = httpHeader
 	}
 
-	if body := repr.Request.Body; body != nil {
-		switch body.Type {
-		case "raw":
-			dst.Request.Body = io.NopCloser(bytes.NewReader([]byte(body.Content)))
-		default:
-			return errors.New(`configio: request.body.type: only "raw" accepted`)
-		}
+	if body := r.Body; body != nil {
+		return body.parseInto(dst, path+".body")
 	}
 
 	return nil
@@ -120,6 +349,14 @@ func (repr representation) parseRunnerInto(dst *benchttp.Runner) error {
 		dst.Interval = parsedInterval
 	}
 
+	if rate := repr.Runner.Rate; rate != nil {
+		dst.Rate = *rate
+	}
+
+	if protocol := repr.Runner.Protocol; protocol != nil {
+		dst.Protocol = benchttp.Protocol(*protocol)
+	}
+
 	if requestTimeout := repr.Runner.RequestTimeout; requestTimeout != nil {
 		parsedTimeout, err := parseOptionalDuration(*requestTimeout)
 		if err != nil {
@@ -136,9 +373,349 @@ func (repr representation) parseRunnerInto(dst *benchttp.Runner) error {
 		dst.GlobalTimeout = parsedGlobalTimeout
 	}
 
+	if duration := repr.Runner.Duration; duration != nil {
+		parsedDuration, err := parseOptionalDuration(*duration)
+		if err != nil {
+			return err
+		}
+		dst.Duration = parsedDuration
+	}
+
+	if warmup := repr.Runner.Warmup; warmup != nil {
+		parsedWarmup, err := parseOptionalDuration(*warmup)
+		if err != nil {
+			return err
+		}
+		dst.Warmup = parsedWarmup
+	}
+
+	if warmupRequests := repr.Runner.WarmupRequests; warmupRequests != nil {
+		dst.WarmupRequests = *warmupRequests
+	}
+
+	if seed := repr.Runner.Seed; seed != nil {
+		dst.Seed = *seed
+	}
+
+	if window := repr.Runner.TimeSeriesWindow; window != nil {
+		parsedWindow, err := parseOptionalDuration(*window)
+		if err != nil {
+			return err
+		}
+		dst.TimeSeriesWindow = parsedWindow
+	}
+
+	if percentiles := repr.Runner.Percentiles; percentiles != nil {
+		dst.Percentiles = percentiles
+	}
+
+	if err := repr.parseStagesInto(dst); err != nil {
+		return err
+	}
+
+	return repr.parseAbortConditionsInto(dst)
+}
+
+func (repr representation) parseStagesInto(dst *benchttp.Runner) error {
+	reprStages := repr.Runner.Stages
+	if len(reprStages) == 0 {
+		return nil
+	}
+
+	stages := make([]benchttp.Stage, len(reprStages))
+	for i, s := range reprStages {
+		fieldPath := func(stageField string) string {
+			return fmt.Sprintf("runner.stages[%d].%s", i, stageField)
+		}
+
+		if err := requireConfigFields(map[string]interface{}{
+			fieldPath("duration"): s.Duration,
+			fieldPath("target"):   s.Target,
+		}); err != nil {
+			return err
+		}
+
+		duration, err := time.ParseDuration(*s.Duration)
+		if err != nil {
+			return fmt.Errorf("%s: %s", fieldPath("duration"), err)
+		}
+
+		stages[i] = benchttp.Stage{Duration: duration, Target: *s.Target}
+	}
+
+	dst.Stages = stages
+	return nil
+}
+
+func (repr representation) parseAbortConditionsInto(dst *benchttp.Runner) error {
+	reprConditions := repr.Runner.AbortConditions
+	if len(reprConditions) == 0 {
+		return nil
+	}
+
+	conditions := make([]benchttp.AbortCondition, len(reprConditions))
+	for i, c := range reprConditions {
+		fieldPath := func(conditionField string) string {
+			return fmt.Sprintf("runner.abortConditions[%d].%s", i, conditionField)
+		}
+
+		condition := benchttp.AbortCondition{}
+
+		if c.FailureRate != nil {
+			condition.FailureRate = *c.FailureRate
+		}
+
+		if c.Latency != nil {
+			latency, err := parseOptionalDuration(*c.Latency)
+			if err != nil {
+				return fmt.Errorf("%s: %s", fieldPath("latency"), err)
+			}
+			condition.Latency = latency
+		}
+
+		if c.Percentile != nil {
+			condition.Percentile = *c.Percentile
+		}
+
+		if c.WindowRequests != nil {
+			condition.WindowRequests = *c.WindowRequests
+		}
+
+		if c.WindowDuration != nil {
+			windowDuration, err := parseOptionalDuration(*c.WindowDuration)
+			if err != nil {
+				return fmt.Errorf("%s: %s", fieldPath("windowDuration"), err)
+			}
+			condition.WindowDuration = windowDuration
+		}
+
+		conditions[i] = condition
+	}
+
+	dst.AbortConditions = conditions
 	return nil
 }
 
+func (repr representation) parseScenarioInto(dst *benchttp.Runner) error {
+	reprSteps := repr.Scenario.Steps
+	if len(reprSteps) == 0 {
+		return nil
+	}
+
+	steps := make([]benchttp.Step, len(reprSteps))
+	for i, s := range reprSteps {
+		fieldPath := func(stepField string) string {
+			return fmt.Sprintf("scenario.steps[%d].%s", i, stepField)
+		}
+
+		if err := requireConfigFields(map[string]interface{}{
+			fieldPath("name"): s.Name,
+		}); err != nil {
+			return err
+		}
+
+		req := &http.Request{}
+		if err := s.Request.parseInto(req, fieldPath("request")); err != nil {
+			return err
+		}
+
+		var extractors []benchttp.Extractor
+		for j, e := range s.Extractors {
+			extractorPath := func(extractorField string) string {
+				return fieldPath(fmt.Sprintf("extractors[%d].%s", j, extractorField))
+			}
+
+			if err := requireConfigFields(map[string]interface{}{
+				extractorPath("var"):        e.Var,
+				extractorPath("type"):       e.Type,
+				extractorPath("expression"): e.Expression,
+			}); err != nil {
+				return err
+			}
+
+			extractors = append(extractors, benchttp.Extractor{
+				Var:        *e.Var,
+				Type:       benchttp.ExtractorType(*e.Type),
+				Expression: *e.Expression,
+			})
+		}
+
+		steps[i] = benchttp.Step{
+			Name:       *s.Name,
+			Request:    req,
+			Extractors: extractors,
+		}
+	}
+
+	dst.Scenario = benchttp.Scenario{Steps: steps}
+	return nil
+}
+
+func (repr representation) parseFeederInto(dst *benchttp.Runner) error {
+	if file := repr.Feeder.File; file != nil {
+		rows, err := readFeederFile(*file)
+		if err != nil {
+			return fmt.Errorf("feeder.file: %s", err)
+		}
+		dst.Feeder.Rows = rows
+	}
+
+	if mode := repr.Feeder.Mode; mode != nil {
+		dst.Feeder.Mode = benchttp.FeederMode(*mode)
+	}
+
+	return nil
+}
+
+func (repr representation) parseAuthInto(dst *benchttp.Runner) {
+	a := repr.Auth
+
+	if a.Type != nil {
+		dst.Auth.Type = benchttp.AuthType(*a.Type)
+	}
+	if a.Username != nil {
+		dst.Auth.Username = *a.Username
+	}
+	if a.Password != nil {
+		dst.Auth.Password = *a.Password
+	}
+	if a.Token != nil {
+		dst.Auth.Token = *a.Token
+	}
+	if a.TokenURL != nil {
+		dst.Auth.TokenURL = *a.TokenURL
+	}
+	if a.ClientID != nil {
+		dst.Auth.ClientID = *a.ClientID
+	}
+	if a.ClientSecret != nil {
+		dst.Auth.ClientSecret = *a.ClientSecret
+	}
+	if a.Scopes != nil {
+		dst.Auth.Scopes = a.Scopes
+	}
+}
+
+// parseSignerInto sets dst.Signer from the signer section. The fields
+// of the section override those of a previous signer of the same type,
+// e.g. from an extended config file.
+func (repr representation) parseSignerInto(dst *benchttp.Runner) error {
+	s := repr.Signer
+
+	typ := ""
+	switch dst.Signer.(type) {
+	case benchttp.HMACSigner:
+		typ = "hmac"
+	case benchttp.SigV4Signer:
+		typ = "sigv4"
+	}
+	if s.Type != nil {
+		typ = *s.Type
+	}
+
+	set := func(dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+
+	switch typ {
+	case "":
+		if s != (representation{}).Signer {
+			return errors.New("signer.type: missing field")
+		}
+	case "hmac":
+		signer, _ := dst.Signer.(benchttp.HMACSigner)
+		set(&signer.Secret, s.Secret)
+		set(&signer.Header, s.Header)
+		set(&signer.TimestampHeader, s.TimestampHeader)
+		dst.Signer = signer
+	case "sigv4":
+		signer, _ := dst.Signer.(benchttp.SigV4Signer)
+		set(&signer.AccessKeyID, s.AccessKeyID)
+		set(&signer.SecretAccessKey, s.SecretAccessKey)
+		set(&signer.SessionToken, s.SessionToken)
+		set(&signer.Region, s.Region)
+		set(&signer.Service, s.Service)
+		dst.Signer = signer
+	default:
+		return fmt.Errorf(`signer.type: unknown type %q: want "hmac" or "sigv4"`, typ)
+	}
+
+	return nil
+}
+
+func (repr representation) parseDialInto(dst *benchttp.Runner) {
+	if resolve := repr.Dial.Resolve; resolve != nil {
+		dst.DialConfig.Resolve = resolve
+	}
+	if dnsCache := repr.Dial.DNSCache; dnsCache != nil {
+		dst.DialConfig.DNSCache = *dnsCache
+	}
+	if unixSocket := repr.Dial.UnixSocket; unixSocket != nil {
+		dst.DialConfig.UnixSocket = *unixSocket
+	}
+}
+
+func (repr representation) parseProxyInto(dst *benchttp.Runner) {
+	if url := repr.Proxy.URL; url != nil {
+		dst.Proxy.URL = *url
+	}
+	if username := repr.Proxy.Username; username != nil {
+		dst.Proxy.Username = *username
+	}
+	if password := repr.Proxy.Password; password != nil {
+		dst.Proxy.Password = *password
+	}
+	if noProxy := repr.Proxy.NoProxy; noProxy != nil {
+		dst.Proxy.NoProxy = noProxy
+	}
+}
+
+func (repr representation) parseChecksInto(dst *benchttp.Runner) error {
+	if len(repr.Checks) == 0 {
+		return nil
+	}
+
+	checks := make([]benchttp.Check, len(repr.Checks))
+	for i, c := range repr.Checks {
+		fieldPath := func(caseField string) string {
+			return fmt.Sprintf("checks[%d].%s", i, caseField)
+		}
+
+		if err := requireConfigFields(map[string]interface{}{
+			fieldPath("type"):       c.Type,
+			fieldPath("expression"): c.Expression,
+		}); err != nil {
+			return err
+		}
+
+		check := benchttp.Check{
+			Type:       benchttp.CheckType(*c.Type),
+			Expression: *c.Expression,
+		}
+		if c.Name != nil {
+			check.Name = *c.Name
+		}
+		if c.Value != nil {
+			check.Value = *c.Value
+		}
+		checks[i] = check
+	}
+
+	dst.Checks = checks
+	return nil
+}
+
+func (repr representation) parseVirtualUsersInto(dst *benchttp.Runner) {
+	if cookies := repr.VirtualUsers.Cookies; cookies != nil {
+		dst.VirtualUsers.Cookies = *cookies
+	}
+	if connection := repr.VirtualUsers.Connection; connection != nil {
+		dst.VirtualUsers.Connection = benchttp.ConnectionPolicy(*connection)
+	}
+}
+
 func (repr representation) parseTestsInto(dst *benchttp.Runner) error {
 	testSuite := repr.Tests
 	if len(testSuite) == 0 {
@@ -161,7 +738,7 @@ func (repr representation) parseTestsInto(dst *benchttp.Runner) error {
 		}
 
 		field := benchttp.MetricsField(*t.Field)
-		if err := field.Validate(); err != nil {
+		if err := field.Validate(dst.Percentiles...); err != nil {
 			return fmt.Errorf("%s: %s", fieldPath("field"), err)
 		}
 
@@ -238,6 +815,8 @@ func parseMetricValue(
 	switch fieldType {
 	case "int":
 		return handleError(strconv.Atoi(inputValue))
+	case "float64":
+		return handleError(strconv.ParseFloat(inputValue, 64))
 	case "time.Duration":
 		return handleError(time.ParseDuration(inputValue))
 	default:
@@ -245,15 +824,45 @@ func parseMetricValue(
 	}
 }
 
+// resolvePath returns path relative to dir if it is relative,
+// else path unchanged.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// requireConfigFields returns an error for the first of fields, sorted
+// by name, whose value is nil, including typed nil pointers.
 func requireConfigFields(fields map[string]interface{}) error {
-	for name, value := range fields {
-		if value == nil {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if isNil(fields[name]) {
 			return fmt.Errorf("%s: missing field", name)
 		}
 	}
 	return nil
 }
 
+// isNil returns true if v is nil or a nil pointer, map, slice
+// or interface.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
 type representations []representation
 
 // mergeInto successively parses the given representations into dst.
@@ -0,0 +1,106 @@
+package configio
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/benchttp/engine/benchttp"
+)
+
+// tlsRepresentation is the raw data model of the tls section.
+type tlsRepresentation struct {
+	CAFile             *string  `yaml:"caFile" json:"caFile"`
+	CertFile           *string  `yaml:"certFile" json:"certFile"`
+	KeyFile            *string  `yaml:"keyFile" json:"keyFile"`
+	InsecureSkipVerify *bool    `yaml:"insecureSkipVerify" json:"insecureSkipVerify"`
+	ServerName         *string  `yaml:"serverName" json:"serverName"`
+	MinVersion         *string  `yaml:"minVersion" json:"minVersion"`
+	MaxVersion         *string  `yaml:"maxVersion" json:"maxVersion"`
+	CipherSuites       []string `yaml:"cipherSuites" json:"cipherSuites"`
+}
+
+// resolvePaths makes the relative file paths of the receiver
+// relative to dir instead.
+func (r *tlsRepresentation) resolvePaths(dir string) {
+	for _, path := range []*string{r.CAFile, r.CertFile, r.KeyFile} {
+		if path != nil {
+			*path = resolvePath(dir, *path)
+		}
+	}
+}
+
+// parseInto stores the non-nil fields of the receiver into dst.
+func (r tlsRepresentation) parseInto(dst *benchttp.TLSConfig) error {
+	if r.CAFile != nil {
+		dst.CAFile = *r.CAFile
+	}
+	if r.CertFile != nil {
+		dst.CertFile = *r.CertFile
+	}
+	if r.KeyFile != nil {
+		dst.KeyFile = *r.KeyFile
+	}
+	if r.InsecureSkipVerify != nil {
+		dst.InsecureSkipVerify = *r.InsecureSkipVerify
+	}
+	if r.ServerName != nil {
+		dst.ServerName = *r.ServerName
+	}
+
+	if r.MinVersion != nil {
+		v, err := parseTLSVersion(*r.MinVersion)
+		if err != nil {
+			return fmt.Errorf("tls.minVersion: %s", err)
+		}
+		dst.MinVersion = v
+	}
+	if r.MaxVersion != nil {
+		v, err := parseTLSVersion(*r.MaxVersion)
+		if err != nil {
+			return fmt.Errorf("tls.maxVersion: %s", err)
+		}
+		dst.MaxVersion = v
+	}
+
+	if r.CipherSuites != nil {
+		suites := make([]uint16, len(r.CipherSuites))
+		for i, name := range r.CipherSuites {
+			id, err := parseCipherSuite(name)
+			if err != nil {
+				return fmt.Errorf("tls.cipherSuites[%d]: %s", i, err)
+			}
+			suites[i] = id
+		}
+		dst.CipherSuites = suites
+	}
+
+	return nil
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	v, ok := tlsVersions[s]
+	if !ok {
+		return 0, fmt.Errorf(`unknown version %q: want "1.0", "1.1", "1.2" or "1.3"`, s)
+	}
+	return v, nil
+}
+
+// parseCipherSuite returns the ID of the cipher suite named s,
+// as named by crypto/tls, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+func parseCipherSuite(s string) (uint16, error) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if suite.Name == s {
+				return suite.ID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher suite %q", s)
+}
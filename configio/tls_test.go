@@ -0,0 +1,95 @@
+package configio_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benchttp/engine/benchttp"
+	"github.com/benchttp/engine/benchttptest"
+	"github.com/benchttp/engine/configio"
+)
+
+func TestUnmarshalFile_tls(t *testing.T) {
+	t.Run("resolve file paths relative to the config file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yml")
+		in := "tls: {caFile: certs/ca.pem, certFile: /etc/client.pem, keyFile: client-key.pem}"
+		if err := os.WriteFile(path, []byte(in), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		want := benchttp.Runner{
+			TLSConfig: benchttp.TLSConfig{
+				CAFile:   filepath.Join(dir, "certs/ca.pem"),
+				CertFile: "/etc/client.pem",
+				KeyFile:  filepath.Join(dir, "client-key.pem"),
+			},
+		}
+
+		got := benchttp.Runner{}
+		if err := configio.UnmarshalFile(path, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		benchttptest.AssertEqualRunners(t, want, got)
+	})
+}
+
+func TestBuilder_DecodeYAML_tls(t *testing.T) {
+	t.Run("parse versions and cipher suites", func(t *testing.T) {
+		want := benchttp.Runner{
+			TLSConfig: benchttp.TLSConfig{
+				InsecureSkipVerify: true,
+				MinVersion:         tls.VersionTLS10,
+				MaxVersion:         tls.VersionTLS12,
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+					tls.TLS_RSA_WITH_RC4_128_SHA, // insecure
+				},
+			},
+		}
+
+		b := configio.Builder{}
+		in := `tls:
+  insecureSkipVerify: true
+  minVersion: "1.0"
+  maxVersion: "1.2"
+  cipherSuites: [TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_RSA_WITH_RC4_128_SHA]`
+		if err := b.DecodeYAML([]byte(in)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		benchttptest.AssertEqualRunners(t, want, b.Runner())
+	})
+
+	t.Run("return errors for invalid fields", func(t *testing.T) {
+		testcases := []struct {
+			in     string
+			expErr string
+		}{
+			{
+				in:     `{minVersion: "1.4"}`,
+				expErr: `tls.minVersion: unknown version "1.4": want "1.0", "1.1", "1.2" or "1.3"`,
+			},
+			{
+				in:     `{maxVersion: "TLS 1.3"}`,
+				expErr: `tls.maxVersion: unknown version "TLS 1.3"`,
+			},
+			{
+				in:     `{cipherSuites: [TLS_AES_128_GCM_SHA256, AES]}`,
+				expErr: `tls.cipherSuites[1]: unknown cipher suite "AES"`,
+			},
+		}
+
+		for _, tc := range testcases {
+			b := configio.Builder{}
+			err := b.DecodeYAML([]byte("tls: " + tc.in))
+			if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("unexpected error:\nexp %s\ngot %v", tc.expErr, err)
+			}
+		}
+	})
+}
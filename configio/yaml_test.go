@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/benchttp/engine/benchttp"
+	"github.com/benchttp/engine/benchttptest"
 	"github.com/benchttp/engine/configio"
 )
 
@@ -57,6 +59,15 @@ func TestYAMLDecoder(t *testing.T) {
 					},
 				},
 			},
+			{
+				label: "unknown field in request mix",
+				in:    []byte("request:\n  - name: a\n    notafield: 123\n"),
+				expErr: &yaml.TypeError{
+					Errors: []string{
+						`line 3: invalid field ("notafield"): does not exist`,
+					},
+				},
+			},
 			{
 				label:  "no errors custom fields",
 				in:     []byte("x-data: &count\n  requests: 100\rrunner:\n  <<: *count\n"),
@@ -90,3 +101,121 @@ func TestYAMLDecoder(t *testing.T) {
 		}
 	})
 }
+
+func TestUnmarshalYAML_signer(t *testing.T) {
+	t.Run("override fields of a same type signer", func(t *testing.T) {
+		want := benchttp.Runner{
+			Signer: benchttp.SigV4Signer{
+				AccessKeyID:     "id",
+				SecretAccessKey: "secret",
+				Region:          "eu-west-1",
+				Service:         "execute-api",
+			},
+		}
+
+		got := benchttp.Runner{}
+		for _, in := range []string{
+			`signer: {type: sigv4, accessKeyID: id, secretAccessKey: secret, region: us-east-1, service: execute-api}`,
+			`signer: {region: eu-west-1}`,
+		} {
+			if err := configio.UnmarshalYAML([]byte(in), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		benchttptest.AssertEqualRunners(t, want, got)
+	})
+
+	t.Run("return errors for invalid signers", func(t *testing.T) {
+		for in, expErr := range map[string]string{
+			`signer: {secret: abc}`: "signer.type: missing field",
+			`signer: {type: rsa}`:   `signer.type: unknown type "rsa": want "hmac" or "sigv4"`,
+		} {
+			err := configio.UnmarshalYAML([]byte(in), &benchttp.Runner{})
+			if err == nil || !strings.Contains(err.Error(), expErr) {
+				t.Errorf("unexpected error:\nexp %s\ngot %v", expErr, err)
+			}
+		}
+	})
+}
+
+func TestUnmarshalYAML_percentiles(t *testing.T) {
+	const testsYAML = `
+tests:
+  - name: p75
+    field: ResponseTimes.P75
+    predicate: LTE
+    target: 100ms
+`
+
+	t.Run("accept tests on configured percentiles", func(t *testing.T) {
+		in := "runner:\n  percentiles: [75]\n" + testsYAML
+		if err := configio.UnmarshalYAML([]byte(in), &benchttp.Runner{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("return error for tests on other percentiles", func(t *testing.T) {
+		expErr := "tests[0].field: metrics: unknown field: ResponseTimes.P75: percentile P75 is not computed"
+		err := configio.UnmarshalYAML([]byte(testsYAML), &benchttp.Runner{})
+		if err == nil || !strings.Contains(err.Error(), expErr) {
+			t.Errorf("unexpected error:\nexp %s\ngot %v", expErr, err)
+		}
+	})
+}
+
+func TestUnmarshalYAML_missingFields(t *testing.T) {
+	for _, tc := range []struct {
+		label  string
+		in     string
+		expErr string
+	}{
+		{
+			label:  "stage without target",
+			in:     "runner:\n  stages:\n    - duration: 10s\n",
+			expErr: "runner.stages[0].target: missing field",
+		},
+		{
+			label:  "stage without duration",
+			in:     "runner:\n  stages:\n    - target: 5\n",
+			expErr: "runner.stages[0].duration: missing field",
+		},
+		{
+			label:  "request mix entry without name",
+			in:     "request:\n  - weight: 1\n    method: GET\n    url: http://localhost\n",
+			expErr: "request[0].name: missing field",
+		},
+		{
+			label:  "request mix entry without weight",
+			in:     "request:\n  - name: a\n    method: GET\n    url: http://localhost\n",
+			expErr: "request[0].weight: missing field",
+		},
+		{
+			label:  "scenario step without name",
+			in:     "scenario:\n  steps:\n    - request:\n        method: GET\n        url: http://localhost\n",
+			expErr: "scenario.steps[0].name: missing field",
+		},
+		{
+			label:  "scenario extractor without var",
+			in:     "scenario:\n  steps:\n    - name: login\n      extractors:\n        - type: json\n          expression: /token\n",
+			expErr: "scenario.steps[0].extractors[0].var: missing field",
+		},
+		{
+			label:  "check without type",
+			in:     "checks:\n  - expression: 2xx\n",
+			expErr: "checks[0].type: missing field",
+		},
+		{
+			label:  "check without expression",
+			in:     "checks:\n  - type: status\n",
+			expErr: "checks[0].expression: missing field",
+		},
+	} {
+		t.Run(tc.label, func(t *testing.T) {
+			err := configio.UnmarshalYAML([]byte(tc.in), &benchttp.Runner{})
+			if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("unexpected error:\nexp %s\ngot %v", tc.expErr, err)
+			}
+		})
+	}
+}
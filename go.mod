@@ -4,6 +4,8 @@ go 1.17
 
 require (
 	github.com/google/go-cmp v0.5.9
-	golang.org/x/sync v0.0.0-20220722155255-886fb9371eb4
+	golang.org/x/net v0.11.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/text v0.10.0 // indirect
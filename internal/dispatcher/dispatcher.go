@@ -5,19 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"sync"
-
-	"golang.org/x/sync/semaphore"
 )
 
 var ErrInvalidValue = errors.New("invalid value")
 
 type Dispatcher interface {
-	Do(ctx context.Context, maxIter int, callback func()) error
+	Do(ctx context.Context, maxIter int, callback func(ctx context.Context)) error
+	// Scale updates the level of load of the Dispatcher, i.e. its number
+	// of workers or its rate, depending on the implementation.
+	// It is safe to call while Do is running.
+	Scale(level int)
 }
 
 type dispatcher struct {
 	numWorker int
-	sem       *semaphore.Weighted
+	limiter   *limiter
 }
 
 // New returns a Dispatcher initialized with numWorker.
@@ -25,13 +27,13 @@ func New(numWorker int) Dispatcher {
 	if numWorker < 1 {
 		panic(fmt.Sprintf("invalid numWorker value: must be > 1, got %d", numWorker))
 	}
-	sem := semaphore.NewWeighted(int64(numWorker))
-	return dispatcher{sem: sem, numWorker: numWorker}
+	return dispatcher{limiter: newLimiter(numWorker), numWorker: numWorker}
 }
 
 // Do concurrently executes callback at most maxIter times or until ctx is done
 // or canceled. Concurrency is handled leveraging the semaphore pattern, which
-// ensures at most Dispatcher.numWorkers goroutines are spawned at the same time.
+// ensures at most numWorker goroutines are spawned at the same time.
+// Each callback is called with ctx, so it can stop early when ctx is done.
 // It returns an early ErrInvalidValue if any of the following conditions is met:
 //
 //	maxIter < 1 and maxIter != -1
@@ -39,7 +41,7 @@ func New(numWorker int) Dispatcher {
 //	callback == nil
 //
 // Else it returns the context error if any or nil.
-func (d dispatcher) Do(ctx context.Context, maxIter int, callback func()) error {
+func (d dispatcher) Do(ctx context.Context, maxIter int, callback func(context.Context)) error {
 	if err := d.validate(maxIter, callback); err != nil {
 		return err
 	}
@@ -52,7 +54,7 @@ func (d dispatcher) Do(ctx context.Context, maxIter int, callback func()) error
 	for i := 0; i < maxIter || maxIter == -1; i++ {
 		wg.Add(1)
 
-		if err = d.sem.Acquire(ctx, 1); err != nil {
+		if err = d.limiter.acquire(ctx); err != nil {
 			// err is either context.DeadlineExceeded or context.Canceled
 			// which are expected values so we stop the process silently.
 			wg.Done()
@@ -61,10 +63,10 @@ func (d dispatcher) Do(ctx context.Context, maxIter int, callback func()) error
 
 		go func() {
 			defer func() {
-				d.sem.Release(1)
+				d.limiter.release()
 				wg.Done()
 			}()
-			callback()
+			callback(ctx)
 		}()
 	}
 
@@ -72,9 +74,20 @@ func (d dispatcher) Do(ctx context.Context, maxIter int, callback func()) error
 	return err
 }
 
-func (d dispatcher) validate(maxIter int, callback func()) error {
-	if maxIter < 1 && maxIter != -1 {
-		return fmt.Errorf("%w: maxIter: must be -1 or >= 1, got %d", ErrInvalidValue, maxIter)
+// Scale updates the maximum number of concurrent callbacks to numWorker.
+// If it is lowered, running callbacks are not interrupted but no new one
+// is started until their number is below numWorker.
+// It panics if numWorker < 0.
+func (d dispatcher) Scale(numWorker int) {
+	if numWorker < 0 {
+		panic(fmt.Sprintf("invalid numWorker value: must be >= 0, got %d", numWorker))
+	}
+	d.limiter.setLimit(numWorker)
+}
+
+func (d dispatcher) validate(maxIter int, callback func(context.Context)) error {
+	if err := validateArgs(maxIter, callback); err != nil {
+		return err
 	}
 	if maxIter < d.numWorker && maxIter != -1 {
 		return fmt.Errorf(
@@ -82,6 +95,15 @@ func (d dispatcher) validate(maxIter int, callback func()) error {
 			ErrInvalidValue, d.numWorker, maxIter,
 		)
 	}
+	return nil
+}
+
+// validateArgs returns an ErrInvalidValue if maxIter or callback
+// are invalid values for any Dispatcher, else nil.
+func validateArgs(maxIter int, callback func(context.Context)) error {
+	if maxIter < 1 && maxIter != -1 {
+		return fmt.Errorf("%w: maxIter: must be -1 or >= 1, got %d", ErrInvalidValue, maxIter)
+	}
 	if callback == nil {
 		return fmt.Errorf("%w: callback: must be non-nil", ErrInvalidValue)
 	}
@@ -55,7 +55,7 @@ func TestDo(t *testing.T) {
 
 		gotIter := 0
 
-		dispatcher.New(numWorker).Do(context.Background(), maxIter, func() { //nolint:errcheck
+		dispatcher.New(numWorker).Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
 			gotIter++
 		})
 
@@ -85,7 +85,7 @@ func TestDo(t *testing.T) {
 
 		var gotErr error
 		gotDuration := timeFunc(func() {
-			gotErr = dispatcher.New(numWorker).Do(ctx, maxIter, func() {
+			gotErr = dispatcher.New(numWorker).Do(ctx, maxIter, func(context.Context) {
 				gotIter++
 				time.Sleep(interval)
 			})
@@ -134,7 +134,7 @@ func TestDo(t *testing.T) {
 
 		var gotErr error
 		gotDuration := timeFunc(func() {
-			gotErr = dispatcher.New(numWorker).Do(ctx, maxIter, func() {
+			gotErr = dispatcher.New(numWorker).Do(ctx, maxIter, func(context.Context) {
 				gotIter++
 				time.Sleep(interval)
 			})
@@ -177,7 +177,7 @@ func TestDo(t *testing.T) {
 			gotNumGoroutines = make([]int, 0, maxIter)
 		)
 
-		dispatcher.New(numWorker).Do(context.Background(), maxIter, func() { //nolint:errcheck
+		dispatcher.New(numWorker).Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
 			mu.Lock()
 			gotNumGoroutines = append(gotNumGoroutines, runtime.NumGoroutine()-baseNumGoroutine)
 			mu.Unlock()
@@ -213,7 +213,7 @@ func TestDo(t *testing.T) {
 		)
 
 		start := time.Now()
-		dispatcher.New(numWorker).Do(context.Background(), maxIter, func() { //nolint:errcheck
+		dispatcher.New(numWorker).Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
 			mu.Lock()
 			elapsedTimes = append(elapsedTimes, time.Since(start))
 			mu.Unlock()
@@ -257,34 +257,114 @@ func TestDo(t *testing.T) {
 	})
 }
 
+func TestScale(t *testing.T) {
+	t.Run("update the number of concurrent workers while running", func(t *testing.T) {
+		const (
+			callbackDuration = 10 * time.Millisecond
+			maxIter          = 60
+		)
+
+		var (
+			mu                   sync.Mutex
+			active               int
+			maxBefore, maxAfter  int
+			scaled               bool
+			numWorker, newWorker = 2, 6
+		)
+
+		d := dispatcher.New(numWorker)
+		go func() {
+			time.Sleep(5 * callbackDuration)
+			mu.Lock()
+			scaled = true
+			mu.Unlock()
+			d.Scale(newWorker)
+		}()
+
+		d.Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
+			mu.Lock()
+			active++
+			if scaled && active > maxAfter {
+				maxAfter = active
+			} else if !scaled && active > maxBefore {
+				maxBefore = active
+			}
+			mu.Unlock()
+
+			time.Sleep(callbackDuration)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+		})
+
+		if maxBefore != numWorker {
+			t.Errorf("max concurrent workers before scaling: exp %d, got %d", numWorker, maxBefore)
+		}
+		if maxAfter != newWorker {
+			t.Errorf("max concurrent workers after scaling: exp %d, got %d", newWorker, maxAfter)
+		}
+	})
+
+	t.Run("pause while numWorker is 0", func(t *testing.T) {
+		const pause = 50 * time.Millisecond
+
+		d := dispatcher.New(1)
+		d.Scale(0)
+		go func() {
+			time.Sleep(pause)
+			d.Scale(1)
+		}()
+
+		start := time.Now()
+		var firstCall time.Duration
+		d.Do(context.Background(), 1, func(context.Context) { //nolint:errcheck
+			firstCall = time.Since(start)
+		})
+
+		if firstCall < pause {
+			t.Errorf("callback called during pause: after %v, want >= %v", firstCall, pause)
+		}
+	})
+
+	t.Run("panic if numWorker < 0", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected to panic but did not")
+			}
+		}()
+		dispatcher.New(1).Scale(-1)
+	})
+}
+
 func TestValidate(t *testing.T) {
 	testcases := []struct {
 		label     string
 		exp       error
 		numWorker int
 		maxIter   int
-		callback  func()
+		callback  func(context.Context)
 	}{
 		{
 			label:     "return error if maxIter == 0",
 			exp:       dispatcher.ErrInvalidValue,
 			numWorker: 10,
 			maxIter:   0,
-			callback:  func() {},
+			callback:  func(context.Context) {},
 		},
 		{
 			label:     "return error if maxIter == -2",
 			exp:       dispatcher.ErrInvalidValue,
 			numWorker: 10,
 			maxIter:   -2,
-			callback:  func() {},
+			callback:  func(context.Context) {},
 		},
 		{
 			label:     "return error if maxIter < numWorker",
 			exp:       dispatcher.ErrInvalidValue,
 			numWorker: 10,
 			maxIter:   5,
-			callback:  func() {},
+			callback:  func(context.Context) {},
 		},
 		{
 			label:     "return error if callback == nil",
@@ -298,14 +378,14 @@ func TestValidate(t *testing.T) {
 			exp:       nil,
 			numWorker: 1,
 			maxIter:   1,
-			callback:  func() {},
+			callback:  func(context.Context) {},
 		},
 		{
 			label:     "return context error on timeout",
 			exp:       context.DeadlineExceeded,
 			numWorker: 1,
 			maxIter:   -1,
-			callback:  func() {},
+			callback:  func(context.Context) {},
 		},
 	}
 
@@ -0,0 +1,70 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter limits the number of concurrent holders of a resource.
+// Contrary to a fixed-size semaphore, its limit can be updated at any time.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	changed chan struct{}
+}
+
+// newLimiter returns a limiter initialized with the given limit.
+func newLimiter(limit int) *limiter {
+	return &limiter{limit: limit, changed: make(chan struct{})}
+}
+
+// acquire blocks until the number of holders is below the limit
+// or ctx is done. On success, it returns nil and the caller must
+// call release once done. Otherwise, it returns the context error.
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// release releases a slot previously obtained via acquire.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.notify()
+}
+
+// setLimit updates the limit. If it is lowered below the current number
+// of holders, no new slot is granted until enough holders are released.
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.notify()
+}
+
+// notify wakes up all goroutines waiting in acquire.
+// It must be called with l.mu held.
+func (l *limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
@@ -0,0 +1,135 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type rateDispatcher struct {
+	mu      sync.Mutex
+	rate    int
+	changed chan struct{}
+}
+
+// NewRate returns a Dispatcher that starts rate callbacks per second
+// on a fixed timeline (open model).
+func NewRate(rate int) Dispatcher {
+	if rate < 1 {
+		panic(fmt.Sprintf("invalid rate value: must be > 1, got %d", rate))
+	}
+	return &rateDispatcher{rate: rate, changed: make(chan struct{})}
+}
+
+// Do executes callback at most maxIter times or until ctx is done
+// or canceled. Contrary to the worker-based Dispatcher, each callback
+// is started at a fixed time, regardless of whether previous callbacks
+// have returned: the i-th callback is started 1s / rate after
+// the (i-1)-th one was scheduled.
+// Each callback is called with ctx, so it can stop early when ctx is done.
+// It returns an early ErrInvalidValue if any of the following conditions
+// is met:
+//
+//	maxIter < 1 and maxIter != -1
+//	callback == nil
+//
+// Else it returns the context error if any or nil.
+func (d *rateDispatcher) Do(ctx context.Context, maxIter int, callback func(context.Context)) error {
+	if err := validateArgs(maxIter, callback); err != nil {
+		return err
+	}
+
+	var (
+		err  error
+		wg   sync.WaitGroup
+		prev time.Time
+	)
+
+	for i := 0; i < maxIter || maxIter == -1; i++ {
+		if prev, err = d.waitNext(ctx, prev); err != nil {
+			// err is either context.DeadlineExceeded or context.Canceled
+			// which are expected values so we stop the process silently.
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			callback(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return err
+}
+
+// Scale updates the number of callbacks started per second to rate.
+// A rate of 0 pauses the dispatch until a higher value is set, the
+// callbacks that would have been started during the pause are skipped.
+// It panics if rate < 0.
+func (d *rateDispatcher) Scale(rate int) {
+	if rate < 0 {
+		panic(fmt.Sprintf("invalid rate value: must be >= 0, got %d", rate))
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rate = rate
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// waitNext blocks until the time the next callback is scheduled,
+// computed from the scheduled time of the previous callback prev
+// and the current rate, or until ctx is done. It returns the scheduled
+// time or the context error.
+// Scheduled times are computed from the previous scheduled time rather
+// than from the actual time so that delays do not accumulate.
+func (d *rateDispatcher) waitNext(ctx context.Context, prev time.Time) (time.Time, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return time.Time{}, err
+		}
+
+		d.mu.Lock()
+		rate, changed := d.rate, d.changed
+		d.mu.Unlock()
+
+		if rate == 0 {
+			select {
+			case <-ctx.Done():
+				return time.Time{}, ctx.Err()
+			case <-changed:
+				// restart the timeline from the end of the pause,
+				// otherwise the slots missed during the pause would
+				// be caught up at once.
+				if !prev.IsZero() {
+					prev = time.Now()
+				}
+				continue
+			}
+		}
+
+		if prev.IsZero() {
+			return time.Now(), nil
+		}
+
+		next := prev.Add(time.Second / time.Duration(rate))
+		wait := time.Until(next)
+		if wait <= 0 {
+			return next, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Time{}, ctx.Err()
+		case <-changed:
+			// recompute the next scheduled time with the new rate
+			timer.Stop()
+		case <-timer.C:
+			return next, nil
+		}
+	}
+}
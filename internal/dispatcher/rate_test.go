@@ -0,0 +1,248 @@
+package dispatcher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benchttp/engine/internal/dispatcher"
+)
+
+func TestNewRate(t *testing.T) {
+	t.Run("panic if rate < 1", func(t *testing.T) {
+		for _, rate := range []int{-1, 0} {
+			func(rate int) {
+				expMessage := fmt.Sprintf("invalid rate value: must be > 1, got %d", rate)
+
+				defer func() {
+					if r := recover(); r != expMessage {
+						t.Errorf("unexpected panic message:\nexp %s\ngot %v", expMessage, r)
+					}
+				}()
+
+				if d := dispatcher.NewRate(rate); d != nil {
+					t.Error("returned a non-nil Dispatcher")
+				}
+			}(rate)
+		}
+	})
+
+	t.Run("return valid Dispatcher if rate > 0", func(t *testing.T) {
+		if d := dispatcher.NewRate(10); d == nil {
+			t.Error("returned nil Dispatcher")
+		}
+	})
+}
+
+func TestRateDo(t *testing.T) {
+	t.Run("stop when maxIter is reached", func(t *testing.T) {
+		const (
+			rate    = 1000
+			maxIter = 10
+		)
+
+		var (
+			mu      sync.Mutex
+			gotIter int
+		)
+
+		err := dispatcher.NewRate(rate).Do(context.Background(), maxIter, func(context.Context) {
+			mu.Lock()
+			gotIter++
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if gotIter != maxIter {
+			t.Errorf("iterations: exp %d, got %d", maxIter, gotIter)
+		}
+	})
+
+	t.Run("start callbacks on a fixed timeline", func(t *testing.T) {
+		const (
+			rate     = 50 // 1 callback every 20ms
+			interval = time.Second / rate
+			maxIter  = 5
+			margin   = 10 * time.Millisecond
+
+			// much longer than the interval: a closed model would be
+			// slowed down by the callback duration.
+			callbackDuration = 5 * interval
+		)
+
+		var (
+			mu       sync.Mutex
+			gotTimes = make([]time.Duration, 0, maxIter)
+		)
+
+		start := time.Now()
+		dispatcher.NewRate(rate).Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
+			mu.Lock()
+			gotTimes = append(gotTimes, time.Since(start))
+			mu.Unlock()
+			time.Sleep(callbackDuration)
+		})
+
+		if len(gotTimes) != maxIter {
+			t.Fatalf("iterations: exp %d, got %d", maxIter, len(gotTimes))
+		}
+
+		for i, gotTime := range gotTimes {
+			expTime := time.Duration(i) * interval
+			if gotTime < expTime || gotTime > expTime+margin {
+				t.Errorf("callback #%d: exp start at %v, got %v", i, expTime, gotTime)
+			}
+		}
+	})
+
+	t.Run("stop on context timeout", func(t *testing.T) {
+		const (
+			timeout = 100 * time.Millisecond
+			rate    = 100 // 1 callback every 10ms
+
+			margin     = 25 * time.Millisecond // determined empirically
+			expIterMax = 12                    // should not be reached
+		)
+
+		var (
+			mu      sync.Mutex
+			gotIter int
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var gotErr error
+		gotDuration := timeFunc(func() {
+			gotErr = dispatcher.NewRate(rate).Do(ctx, -1, func(context.Context) {
+				mu.Lock()
+				gotIter++
+				mu.Unlock()
+			})
+		})
+
+		if expErr := context.DeadlineExceeded; !errors.Is(gotErr, expErr) {
+			t.Errorf("unexpected error:\nexp %v\ngot %v", expErr, gotErr)
+		}
+
+		if maxDuration := timeout + margin; gotDuration > maxDuration {
+			t.Errorf(
+				"context timeout duration: exp < %dms, got %dms",
+				maxDuration.Milliseconds(), gotDuration.Milliseconds(),
+			)
+		}
+
+		if gotIter >= expIterMax {
+			t.Errorf("context timeout iterations: exp < %d, got %d", expIterMax, gotIter)
+		}
+	})
+
+	t.Run("use scaled rate", func(t *testing.T) {
+		const (
+			rate    = 20 // 1 callback every 50ms
+			newRate = 200
+			maxIter = 12
+			margin  = 40 * time.Millisecond
+		)
+
+		d := dispatcher.NewRate(rate)
+		d.Scale(newRate)
+
+		// At the initial rate, maxIter callbacks would take ~550ms.
+		expMaxDuration := time.Duration(maxIter)*time.Second/newRate + margin
+
+		gotDuration := timeFunc(func() {
+			d.Do(context.Background(), maxIter, func(context.Context) {}) //nolint:errcheck
+		})
+
+		if gotDuration > expMaxDuration {
+			t.Errorf("exp duration < %v, got %v", expMaxDuration, gotDuration)
+		}
+	})
+
+	t.Run("pause while rate is 0", func(t *testing.T) {
+		const pause = 50 * time.Millisecond
+
+		d := dispatcher.NewRate(100)
+		d.Scale(0)
+		go func() {
+			time.Sleep(pause)
+			d.Scale(100)
+		}()
+
+		start := time.Now()
+		var firstCall time.Duration
+		d.Do(context.Background(), 1, func(context.Context) { //nolint:errcheck
+			firstCall = time.Since(start)
+		})
+
+		if firstCall < pause {
+			t.Errorf("callback called during pause: after %v, want >= %v", firstCall, pause)
+		}
+	})
+
+	t.Run("keep the rate after a pause", func(t *testing.T) {
+		const (
+			rate     = 50 // 1 callback every 20ms
+			interval = time.Second / rate
+			pause    = 10 * interval
+			maxIter  = 6
+			margin   = interval / 2
+		)
+
+		var (
+			mu       sync.Mutex
+			gotTimes = make([]time.Time, 0, maxIter)
+		)
+
+		d := dispatcher.NewRate(rate)
+		d.Do(context.Background(), maxIter, func(context.Context) { //nolint:errcheck
+			mu.Lock()
+			gotTimes = append(gotTimes, time.Now())
+			n := len(gotTimes)
+			mu.Unlock()
+			if n == 2 {
+				d.Scale(0)
+				time.AfterFunc(pause, func() { d.Scale(rate) })
+			}
+		})
+
+		if len(gotTimes) != maxIter {
+			t.Fatalf("iterations: exp %d, got %d", maxIter, len(gotTimes))
+		}
+
+		if gap := gotTimes[2].Sub(gotTimes[1]); gap < pause {
+			t.Errorf("callback #2: exp start after the pause of %v, got %v", pause, gap)
+		}
+		// Callbacks missed during the pause must not be started at once.
+		for i := 3; i < maxIter; i++ {
+			if gap := gotTimes[i].Sub(gotTimes[i-1]); gap < interval-margin {
+				t.Errorf("callback #%d: exp start %v after #%d, got %v", i, interval, i-1, gap)
+			}
+		}
+	})
+
+	t.Run("return error for invalid values", func(t *testing.T) {
+		for _, tc := range []struct {
+			label    string
+			maxIter  int
+			callback func(context.Context)
+		}{
+			{label: "maxIter == 0", maxIter: 0, callback: func(context.Context) {}},
+			{label: "maxIter == -2", maxIter: -2, callback: func(context.Context) {}},
+			{label: "callback == nil", maxIter: 1, callback: nil},
+		} {
+			t.Run(tc.label, func(t *testing.T) {
+				err := dispatcher.NewRate(1).Do(context.Background(), tc.maxIter, tc.callback)
+				if !errors.Is(err, dispatcher.ErrInvalidValue) {
+					t.Errorf("unexpected error:\nexp %v\ngot %v", dispatcher.ErrInvalidValue, err)
+				}
+			})
+		}
+	})
+}